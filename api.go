@@ -2,6 +2,7 @@ package godoist
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -21,6 +22,17 @@ type paginatedResponse struct {
 	NextCursor *string         `json:"next_cursor"`
 }
 
+// APIError is returned when the API answers with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %s: %s", e.Status, e.Body)
+}
+
 type TodoistAPI struct {
 	Token  string
 	logger *slog.Logger
@@ -50,7 +62,7 @@ func (t *TodoistAPI) doGet(path string, result interface{}) error {
 		return err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("API error %s: %s", resp.Status, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 	}
 
 	return json.Unmarshal(body, result)
@@ -90,7 +102,7 @@ func (t *TodoistAPI) doGetPaginated(path string, result interface{}) error {
 			return err
 		}
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			return fmt.Errorf("API error %s: %s", resp.Status, string(body))
+			return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 		}
 
 		var page paginatedResponse
@@ -143,7 +155,7 @@ func (t *TodoistAPI) doPost(path string, payload interface{}, result interface{}
 		return err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("API error %s: %s", resp.Status, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 	}
 
 	if result != nil && len(body) > 0 {
@@ -167,7 +179,7 @@ func (t *TodoistAPI) doPostNoBody(path string) error {
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %s: %s", resp.Status, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 	}
 	return nil
 }
@@ -187,7 +199,7 @@ func (t *TodoistAPI) doDelete(path string) error {
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %s: %s", resp.Status, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 	}
 	return nil
 }
@@ -204,6 +216,15 @@ func (t *TodoistAPI) GetProjects() ([]Project, error) {
 	return projects, err
 }
 
+func (t *TodoistAPI) GetTask(id string) (*Task, error) {
+	var task Task
+	err := t.doGet("/tasks/"+id, &task)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
 func (t *TodoistAPI) CreateTask(fields map[string]interface{}) (*Task, error) {
 	var task Task
 	err := t.doPost("/tasks", fields, &task)
@@ -217,6 +238,16 @@ func (t *TodoistAPI) UpdateTask(id string, fields map[string]interface{}) error
 	return t.doPost("/tasks/"+id, fields, nil)
 }
 
+// PatchTask updates a task and returns it as stored by the server.
+func (t *TodoistAPI) PatchTask(id string, fields map[string]interface{}) (*Task, error) {
+	var task Task
+	err := t.doPost("/tasks/"+id, fields, &task)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
 func (t *TodoistAPI) CloseTask(id string) error {
 	return t.doPostNoBody("/tasks/" + id + "/close")
 }
@@ -239,14 +270,26 @@ func (t *TodoistAPI) UpdateProject(id string, fields map[string]interface{}) err
 }
 
 type SyncResponse struct {
-	Items    []Task    `json:"items"`
-	Projects []Project `json:"projects"`
+	SyncToken string    `json:"sync_token"`
+	FullSync  bool      `json:"full_sync"`
+	Items     []Task    `json:"items"`
+	Projects  []Project `json:"projects"`
+	Notes     []Note    `json:"notes"`
 }
 
 // SyncResources fetches specified resources using the sync endpoint
 func (t *TodoistAPI) SyncResources(resourceTypes []string) (*SyncResponse, error) {
+	return t.SyncResourcesSince("*", resourceTypes)
+}
+
+// SyncResourcesSince fetches the specified resources that changed since the
+// given sync token. A token of "*" requests a full sync.
+func (t *TodoistAPI) SyncResourcesSince(syncToken string, resourceTypes []string) (*SyncResponse, error) {
+	if syncToken == "" {
+		syncToken = "*"
+	}
 	payload := map[string]interface{}{
-		"sync_token":     "*",
+		"sync_token":     syncToken,
 		"resource_types": resourceTypes,
 	}
 
@@ -257,3 +300,70 @@ func (t *TodoistAPI) SyncResources(resourceTypes []string) (*SyncResponse, error
 	}
 	return &syncResp, nil
 }
+
+// Command is a single write in the Sync API command format.
+type Command struct {
+	Type   string                 `json:"type"`
+	UUID   string                 `json:"uuid"`
+	TempID string                 `json:"temp_id,omitempty"`
+	Args   map[string]interface{} `json:"args"`
+}
+
+// NewCommand creates a command with a fresh UUID.
+func NewCommand(cmdType string, args map[string]interface{}) Command {
+	return Command{Type: cmdType, UUID: newUUID(), Args: args}
+}
+
+// CommandResponse is the result of executing commands via the sync endpoint.
+type CommandResponse struct {
+	SyncToken     string                     `json:"sync_token"`
+	SyncStatus    map[string]json.RawMessage `json:"sync_status"`
+	TempIDMapping map[string]string          `json:"temp_id_mapping"`
+}
+
+// Err returns the error reported for the command with the given UUID, or nil
+// if it succeeded.
+func (r *CommandResponse) Err(uuid string) error {
+	status, ok := r.SyncStatus[uuid]
+	if !ok {
+		return fmt.Errorf("no status for command %s", uuid)
+	}
+	var result string
+	if json.Unmarshal(status, &result) == nil && result == "ok" {
+		return nil
+	}
+	var cmdErr struct {
+		Error     string `json:"error"`
+		ErrorCode int    `json:"error_code"`
+	}
+	if err := json.Unmarshal(status, &cmdErr); err != nil {
+		return fmt.Errorf("command %s failed: %s", uuid, string(status))
+	}
+	return fmt.Errorf("command %s failed: %s (code %d)", uuid, cmdErr.Error, cmdErr.ErrorCode)
+}
+
+// ExecuteCommands sends a batch of commands to the sync endpoint. The Sync
+// API accepts at most 100 commands per request.
+func (t *TodoistAPI) ExecuteCommands(commands []Command) (*CommandResponse, error) {
+	payload := map[string]interface{}{
+		"commands": commands,
+	}
+
+	var cmdResp CommandResponse
+	err := t.doPost("/sync", payload, &cmdResp)
+	if err != nil {
+		return nil, err
+	}
+	return &cmdResp, nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
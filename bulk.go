@@ -0,0 +1,211 @@
+package godoist
+
+import (
+	"errors"
+	"slices"
+)
+
+// commandBatchSize is the maximum number of commands the Sync API accepts
+// per request.
+const commandBatchSize = 100
+
+// BulkResult is the outcome of a bulk operation for a single task. Err is
+// nil if the change was applied or, when offline, journaled.
+type BulkResult struct {
+	Task *Task
+	Err  error
+}
+
+// BulkReport lists the outcome of a bulk operation per task.
+type BulkReport struct {
+	Results []BulkResult
+}
+
+// Failed returns the results of the tasks that could not be changed.
+func (r *BulkReport) Failed() []BulkResult {
+	var failed []BulkResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of all failed tasks, nil if every task succeeded.
+func (r *BulkReport) Err() error {
+	var errs []error
+	for _, result := range r.Failed() {
+		errs = append(errs, result.Err)
+	}
+	return errors.Join(errs...)
+}
+
+// Bulk applies the same change to many tasks with as few Sync API
+// requests as possible.
+type Bulk struct {
+	manager *TaskManager
+	tasks   []*Task
+}
+
+// Bulk returns bulk operations on tasks.
+func (t *TaskManager) Bulk(tasks []*Task) *Bulk {
+	return &Bulk{manager: t, tasks: tasks}
+}
+
+// BulkWhere returns bulk operations on all tasks matching keep.
+func (t *TaskManager) BulkWhere(keep func(*Task) bool) *Bulk {
+	var tasks []*Task
+	for _, task := range t.All() {
+		if keep(task) {
+			tasks = append(tasks, task)
+		}
+	}
+	return t.Bulk(tasks)
+}
+
+// Tasks returns the tasks the operations apply to.
+func (b *Bulk) Tasks() []*Task {
+	return b.tasks
+}
+
+// Close completes the tasks.
+func (b *Bulk) Close() *BulkReport {
+	return b.run(func(task *Task) Command {
+		return NewCommand("item_close", map[string]interface{}{"id": task.ID})
+	}, func(task *Task) {
+		task.Checked = true
+	})
+}
+
+// Reopen uncompletes the tasks.
+func (b *Bulk) Reopen() *BulkReport {
+	return b.run(func(task *Task) Command {
+		return NewCommand("item_uncomplete", map[string]interface{}{"id": task.ID})
+	}, func(task *Task) {
+		task.Checked = false
+	})
+}
+
+// Delete deletes the tasks and their subtasks.
+func (b *Bulk) Delete() *BulkReport {
+	return b.run(func(task *Task) Command {
+		return NewCommand("item_delete", map[string]interface{}{"id": task.ID})
+	}, func(task *Task) {
+		for _, child := range task.Descendants() {
+			b.manager.removeTask(child.ID)
+		}
+		b.manager.removeTask(task.ID)
+	})
+}
+
+// MoveToProject moves the tasks to the top level of a project.
+func (b *Bulk) MoveToProject(projectID string) *BulkReport {
+	return b.run(func(task *Task) Command {
+		return moveCommand(task.ID, "", "", projectID)
+	}, func(task *Task) {
+		task.setPlacement("", "", projectID)
+	})
+}
+
+// MoveToSection moves the tasks to the top level of a section, which may
+// belong to another project.
+func (b *Bulk) MoveToSection(sectionID string) *BulkReport {
+	projectID, err := b.manager.sectionProject(sectionID)
+	if err != nil {
+		return b.fail(err)
+	}
+	return b.run(func(task *Task) Command {
+		return moveCommand(task.ID, "", sectionID, "")
+	}, func(task *Task) {
+		task.setPlacement("", sectionID, projectID)
+	})
+}
+
+// AddLabel adds label to the tasks not having it yet.
+func (b *Bulk) AddLabel(label string) *BulkReport {
+	return b.updateLabels(func(labels []string) []string {
+		if slices.Contains(labels, label) {
+			return labels
+		}
+		return append(slices.Clone(labels), label)
+	})
+}
+
+// RemoveLabel removes label from the tasks having it.
+func (b *Bulk) RemoveLabel(label string) *BulkReport {
+	return b.updateLabels(func(labels []string) []string {
+		return slices.DeleteFunc(slices.Clone(labels), func(l string) bool { return l == label })
+	})
+}
+
+func (b *Bulk) updateLabels(change func([]string) []string) *BulkReport {
+	labels := make(map[string][]string, len(b.tasks))
+	for _, task := range b.tasks {
+		labels[task.ID] = change(task.Labels)
+	}
+	return b.run(func(task *Task) Command {
+		return NewCommand("item_update", map[string]interface{}{"id": task.ID, "labels": labels[task.ID]})
+	}, func(task *Task) {
+		task.Labels = labels[task.ID]
+		b.manager.Reindex(task)
+	})
+}
+
+// SetPriority sets the priority of the tasks.
+func (b *Bulk) SetPriority(priority PRIORITY_LEVEL) *BulkReport {
+	return b.run(func(task *Task) Command {
+		return NewCommand("item_update", map[string]interface{}{"id": task.ID, "priority": priority})
+	}, func(task *Task) {
+		task.Priority = priority
+	})
+}
+
+// fail reports err for every task without changing any.
+func (b *Bulk) fail(err error) *BulkReport {
+	report := &BulkReport{Results: make([]BulkResult, 0, len(b.tasks))}
+	for _, task := range b.tasks {
+		report.Results = append(report.Results, BulkResult{Task: task, Err: err})
+	}
+	return report
+}
+
+// run sends a command per task in batches and applies the change locally
+// for every task the API accepted. If the client is or goes offline, the
+// remaining commands are journaled instead.
+func (b *Bulk) run(command func(*Task) Command, apply func(*Task)) *BulkReport {
+	report := &BulkReport{Results: make([]BulkResult, 0, len(b.tasks))}
+	for batch := range slices.Chunk(b.tasks, commandBatchSize) {
+		cmds := make([]Command, len(batch))
+		for i, task := range batch {
+			cmds[i] = command(task)
+		}
+
+		var resp *CommandResponse
+		err := b.manager.submit(nil, func() (err error) {
+			resp, err = b.manager.api.ExecuteCommands(cmds)
+			return err
+		})
+
+		var written []*Task
+		for i, task := range batch {
+			result := BulkResult{Task: task, Err: err}
+			switch {
+			case err != nil:
+			case resp == nil:
+				result.Err = b.manager.queue(task, cmds[i])
+			default:
+				result.Err = resp.Err(cmds[i].UUID)
+			}
+			if result.Err == nil {
+				apply(task)
+				if resp != nil && b.manager.Get(task.ID) == task {
+					written = append(written, task)
+				}
+			}
+			report.Results = append(report.Results, result)
+		}
+		b.manager.refresh(written...)
+	}
+	return report
+}
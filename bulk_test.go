@@ -0,0 +1,95 @@
+package godoist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBulk(t *testing.T) {
+	var batches []int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sync", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload struct {
+			Commands []Command `json:"commands"`
+		}
+		json.Unmarshal(body, &payload)
+		batches = append(batches, len(payload.Commands))
+
+		status := map[string]interface{}{}
+		for _, cmd := range payload.Commands {
+			status[cmd.UUID] = "ok"
+			if cmd.Args["id"] == "7" {
+				status[cmd.UUID] = map[string]interface{}{"error_code": 22, "error": "Item not found"}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"sync_status": status})
+	})
+	mux.HandleFunc("GET /sections/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "s2" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(Section{ID: "s2", ProjectID: "200"})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	tasks := make([]Task, 0, 250)
+	for i := 0; i < 250; i++ {
+		tasks = append(tasks, Task{ID: fmt.Sprint(i), ProjectID: "100"})
+	}
+	td.Tasks.Update(tasks)
+
+	report := td.Tasks.BulkWhere(func(task *Task) bool { return true }).AddLabel("triage")
+	if fmt.Sprint(batches) != "[100 100 50]" {
+		t.Errorf("expected batches of 100, got %v", batches)
+	}
+	if len(report.Results) != 250 {
+		t.Fatalf("expected a result per task, got %d", len(report.Results))
+	}
+	failed := report.Failed()
+	if len(failed) != 1 || failed[0].Task.ID != "7" || report.Err() == nil {
+		t.Fatalf("expected only task 7 to fail, got %v", failed)
+	}
+	if got := td.Tasks.ByLabel("triage"); len(got) != 249 {
+		t.Errorf("expected 249 relabeled tasks, got %d", len(got))
+	}
+
+	report = td.Tasks.Bulk([]*Task{td.Tasks.Get("1"), td.Tasks.Get("2")}).Delete()
+	if report.Err() != nil {
+		t.Fatalf("Delete() returned error: %v", report.Err())
+	}
+	if td.Tasks.Get("1") != nil || td.Tasks.Get("2") != nil {
+		t.Error("expected deleted tasks to be removed from the cache")
+	}
+
+	report = td.Tasks.Bulk([]*Task{td.Tasks.Get("3")}).SetPriority(HIGH)
+	if report.Err() != nil || td.Tasks.Get("3").Priority != HIGH {
+		t.Errorf("expected priority to be set, got %v", report.Err())
+	}
+
+	report = td.Tasks.Bulk([]*Task{td.Tasks.Get("3"), td.Tasks.Get("4")}).MoveToSection("s2")
+	if report.Err() != nil {
+		t.Fatalf("MoveToSection() returned error: %v", report.Err())
+	}
+	if task := td.Tasks.Get("4"); task.SectionID != "s2" || task.ProjectID != "200" {
+		t.Errorf("expected task 4 in section s2 of project 200, got section %q project %q", task.SectionID, task.ProjectID)
+	}
+
+	report = td.Tasks.Bulk([]*Task{td.Tasks.Get("5")}).MoveToSection("missing")
+	if len(report.Failed()) != 1 || td.Tasks.Get("5").SectionID != "" {
+		t.Errorf("expected move to an unknown section to fail, got %v", report.Err())
+	}
+}
@@ -8,9 +8,29 @@ type Comment struct {
 	ProjectID string `json:"project_id"`
 }
 
-// GetComments retrieves all comments for a task
+// Note is a task comment as returned by the Sync API.
+type Note struct {
+	ID        string `json:"id"`
+	ItemID    string `json:"item_id"`
+	Content   string `json:"content"`
+	PostedAt  string `json:"posted_at"`
+	IsDeleted bool   `json:"is_deleted"`
+}
+
+// Comment converts the note to its REST representation.
+func (n Note) Comment() Comment {
+	return Comment{ID: n.ID, TaskID: n.ItemID, Content: n.Content, PostedAt: n.PostedAt}
+}
+
+// GetComments retrieves all comments for a task. Their text is added to
+// the search index, see SearchOptions.Comments.
 func (t *Task) GetComments() ([]Comment, error) {
-	return t.manager.api.GetComments(t.ID)
+	comments, err := t.manager.api.GetComments(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	t.manager.indexes.commentSearch.indexComments(t.ID, comments)
+	return comments, nil
 }
 
 // CreateComment creates a comment for a task
@@ -35,6 +55,28 @@ func (api *TodoistAPI) GetComments(taskID string) ([]Comment, error) {
 	return comments, err
 }
 
+// CreateProjectComment creates a comment for a project
+func (api *TodoistAPI) CreateProjectComment(projectID, content string) (*Comment, error) {
+	payload := map[string]interface{}{
+		"project_id": projectID,
+		"content":    content,
+	}
+
+	var comment Comment
+	err := api.doPost("/comments", payload, &comment)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetProjectComments retrieves all comments for a project
+func (api *TodoistAPI) GetProjectComments(projectID string) ([]Comment, error) {
+	var comments []Comment
+	err := api.doGetPaginated("/comments?project_id="+projectID, &comments)
+	return comments, err
+}
+
 // UpdateComment updates a comment by its ID
 func (api *TodoistAPI) UpdateComment(commentID, content string) error {
 	payload := map[string]interface{}{
@@ -21,6 +21,20 @@ type Config struct {
 	Timeout    int    `koanf:"timeout"`
 	Debug      bool   `koanf:"debug"`
 	UseSyncAPI bool   `koanf:"use_sync_api"`
+	// CachePath enables the on-disk cache of synced state when set.
+	CachePath string `koanf:"cache_path"`
+	// CacheMaxAge is the number of seconds cached state stays fresh.
+	// Zero always syncs, a negative value never expires the cache.
+	CacheMaxAge int `koanf:"cache_max_age"`
+	// CacheContexts reads contexts from the cache instead of the API, see
+	// Todoist.CacheContextReads.
+	CacheContexts bool `koanf:"cache_contexts"`
+	// JournalPath enables offline mode, journaling writes to this file
+	// while the API cannot be reached.
+	JournalPath string `koanf:"journal_path"`
+	// ContextKeys enables encrypted contexts. The base64 encoded AES keys
+	// are tried in order for reading, the first one is used for writing.
+	ContextKeys []string `koanf:"context_keys"`
 }
 
 func (config Config) Merge(other *Config) {
@@ -32,11 +46,14 @@ func (config Config) Merge(other *Config) {
 
 func defaultConfig() *Config {
 	return &Config{
-		Token:      "",
-		ApiURL:     "https://api.todoist.com/api/v1",
-		Timeout:    30,
-		Debug:      false,
-		UseSyncAPI: false,
+		Token:       "",
+		ApiURL:      "https://api.todoist.com/api/v1",
+		Timeout:     30,
+		Debug:       false,
+		UseSyncAPI:  false,
+		CachePath:   "",
+		CacheMaxAge: 0,
+		JournalPath: "",
 	}
 }
 
@@ -1,87 +1,476 @@
 package godoist
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
 	"strings"
 )
 
 const ContextPrefix = "[CONTEXT]"
 
-// getContextComment retrieves the existing context comment for a task, if any
-func (t *Task) getContextComment() (*Comment, error) {
-	comments, err := t.manager.api.GetComments(t.ID)
+// RevisionKey is the field of the context data holding its revision. It
+// is managed by the library and not returned by GetContext.
+const RevisionKey = "_rev"
+
+// MaxContextRetries is how often UpdateContext and friends retry a write
+// that conflicted with a concurrent one.
+var MaxContextRetries = 5
+
+// ErrContextConflict is returned when the context of a task was changed by
+// someone else while it was being written.
+var ErrContextConflict = errors.New("context was modified concurrently")
+
+// Context is the context data of a task or project within one namespace.
+// Every namespace is stored in its own comment, so tools using different
+// namespaces never overwrite each other.
+type Context struct {
+	manager   *TaskManager
+	owner     contextOwner
+	namespace string
+}
+
+// contextOwner is what context comments are attached to.
+type contextOwner interface {
+	// ownerID identifies the owner in logs, caches and encrypted payloads.
+	ownerID() string
+	comments() ([]Comment, error)
+	createComment(content string) (*Comment, error)
+	updateComment(comment Comment, content string) error
+	deleteComment(comment Comment) error
+	// preloaded returns the contexts loaded by LoadContexts, nil if there
+	// are none.
+	preloaded() map[string]cachedContext
+	invalidate()
+}
+
+// taskOwner attaches contexts to tasks through the configured
+// ContextStore.
+type taskOwner struct{ task *Task }
+
+func (o taskOwner) ownerID() string { return o.task.ID }
+
+func (o taskOwner) comments() ([]Comment, error) {
+	return o.task.manager.contextStore.Comments(o.task)
+}
+
+func (o taskOwner) createComment(content string) (*Comment, error) {
+	return o.task.manager.contextStore.Create(o.task, content)
+}
+
+func (o taskOwner) updateComment(comment Comment, content string) error {
+	return o.task.manager.contextStore.Update(o.task, comment, content)
+}
+
+func (o taskOwner) deleteComment(comment Comment) error {
+	return o.task.manager.contextStore.Delete(o.task, comment)
+}
+
+func (o taskOwner) preloaded() map[string]cachedContext { return o.task.contexts }
+func (o taskOwner) invalidate()                         { o.task.contexts = nil }
+
+// Context returns the context of the task in namespace. The empty
+// namespace is the default context used by GetContext and SetContext.
+// Namespaces must not contain whitespace or square brackets.
+func (t *Task) Context(namespace string) *Context {
+	return &Context{manager: t.manager, owner: taskOwner{t}, namespace: namespace}
+}
+
+// Namespace returns the namespace of the context.
+func (c *Context) Namespace() string {
+	return c.namespace
+}
+
+// contextPrefix returns the prefix marking context comments of namespace.
+func contextPrefix(namespace string) string {
+	if namespace == "" {
+		return ContextPrefix
+	}
+	return "[CONTEXT:" + namespace + "]"
+}
+
+// contextNamespace returns the namespace of a context comment, and false
+// if content is not a context comment.
+func contextNamespace(content string) (string, bool) {
+	namespace, _, _, ok := parseContextHeader(content)
+	return namespace, ok
+}
+
+// parseContextHeader splits a context comment into its namespace, whether
+// it is encrypted and the payload following the prefix.
+func parseContextHeader(content string) (namespace string, encrypted bool, payload string, ok bool) {
+	rest, ok := strings.CutPrefix(content, "[CONTEXT")
+	if !ok {
+		return "", false, "", false
+	}
+	rest, encrypted = strings.CutPrefix(rest, "-ENC")
+	if after, isDefault := strings.CutPrefix(rest, "]"); isDefault {
+		return "", encrypted, strings.TrimSpace(after), true
+	}
+	rest, ok = strings.CutPrefix(rest, ":")
+	if !ok {
+		return "", false, "", false
+	}
+	namespace, payload, ok = strings.Cut(rest, "]")
+	if !ok || namespace == "" || validateNamespace(namespace) != nil {
+		return "", false, "", false
+	}
+	return namespace, encrypted, strings.TrimSpace(payload), true
+}
+
+func validateNamespace(namespace string) error {
+	if strings.ContainsAny(namespace, "[] \t\r\n") {
+		return fmt.Errorf("invalid context namespace %q", namespace)
+	}
+	return nil
+}
+
+// Namespaces returns the namespaces of the contexts stored on the task in
+// alphabetical order. The default context is listed as the empty string.
+func (t *Task) Namespaces() ([]string, error) {
+	return contextNamespaces(taskOwner{t})
+}
+
+func contextNamespaces(owner contextOwner) ([]string, error) {
+	comments, err := owner.comments()
 	if err != nil {
 		return nil, err
 	}
 
+	var namespaces []string
 	for _, comment := range comments {
-		if strings.HasPrefix(comment.Content, ContextPrefix) {
+		namespace, ok := contextNamespace(comment.Content)
+		if ok && !isHistoryNamespace(namespace) && !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	slices.Sort(namespaces)
+	return namespaces, nil
+}
+
+// getComment retrieves the existing context comment, if any
+func (c *Context) getComment() (*Comment, error) {
+	if c.manager.readCachedContexts && c.manager.cachesContexts() {
+		if comment, ok := c.manager.contexts[c.owner.ownerID()][c.namespace]; ok {
 			return &comment, nil
 		}
 	}
-	return nil, nil
+	return c.fetchComment()
 }
 
-// GetContext retrieves the context data for a task
-func (t *Task) GetContext() (map[string]interface{}, error) {
-	comment, err := t.getContextComment()
+// fetchComment retrieves the context comment from the API, bypassing the
+// cache.
+func (c *Context) fetchComment() (*Comment, error) {
+	if err := validateNamespace(c.namespace); err != nil {
+		return nil, err
+	}
+	comments, err := c.owner.comments()
 	if err != nil {
 		return nil, err
 	}
 
+	for _, comment := range comments {
+		if namespace, ok := contextNamespace(comment.Content); ok && namespace == c.namespace {
+			c.manager.cacheContextComment(comment)
+			return &comment, nil
+		}
+	}
+	c.uncache()
+	return nil, nil
+}
+
+// cacheContextComment records a task context comment so that it can be
+// persisted alongside the rest of the synced state.
+func (t *TaskManager) cacheContextComment(comment Comment) {
+	namespace, ok := contextNamespace(comment.Content)
+	if !t.cachesContexts() || !ok || comment.TaskID == "" {
+		return
+	}
+	if t.contexts[comment.TaskID] == nil {
+		t.contexts[comment.TaskID] = make(map[string]Comment)
+	}
+	t.contexts[comment.TaskID][namespace] = comment
+}
+
+// cachesContexts reports whether context comments are cached. Only actual
+// comments are: other stores, such as DescriptionContextStore, read from
+// task fields that are already kept up to date by syncs.
+func (t *TaskManager) cachesContexts() bool {
+	_, comments := t.contextStore.(CommentContextStore)
+	return t.cacheContexts && comments
+}
+
+func (c *Context) uncache() {
+	delete(c.manager.contexts[c.owner.ownerID()], c.namespace)
+}
+
+// commentOwnerID returns the ownerID of the task or project a comment is
+// attached to.
+func commentOwnerID(comment Comment) string {
+	if comment.TaskID != "" {
+		return comment.TaskID
+	}
+	return projectOwnerPrefix + comment.ProjectID
+}
+
+// parseContext decodes the context data of a comment, decrypting it if
+// necessary, and splits off its revision. A missing comment is an empty
+// context at revision 0.
+func (t *TaskManager) parseContext(comment *Comment) (map[string]interface{}, int, error) {
+	contextData, rev, _, err := t.decodeContext(comment)
+	return contextData, rev, err
+}
+
+// decodeContext is parseContext that also returns the JSON payload of the
+// comment, nil for a missing comment.
+func (t *TaskManager) decodeContext(comment *Comment) (map[string]interface{}, int, []byte, error) {
 	if comment == nil {
-		return make(map[string]interface{}), nil
+		return make(map[string]interface{}), 0, nil, nil
+	}
+
+	namespace, contextJSON, err := t.contextPayload(comment)
+	if err != nil {
+		return nil, 0, nil, &ContextParseError{Namespace: namespace, Raw: comment.Content, Err: err}
 	}
 
-	contextJSON := strings.TrimPrefix(comment.Content, ContextPrefix+" ")
 	var contextData map[string]interface{}
-	if err := json.Unmarshal([]byte(contextJSON), &contextData); err != nil {
-		return nil, fmt.Errorf("failed to parse context: %w", err)
+	if err := json.Unmarshal(contextJSON, &contextData); err != nil {
+		return nil, 0, nil, &ContextParseError{Namespace: namespace, Raw: comment.Content, Err: err}
+	}
+	if contextData == nil {
+		contextData = make(map[string]interface{})
 	}
 
-	return contextData, nil
+	rev, _ := contextData[RevisionKey].(float64)
+	delete(contextData, RevisionKey)
+	return contextData, int(rev), contextJSON, nil
 }
 
-// SetContext sets or updates the context data for a task
-func (t *Task) SetContext(contextData map[string]interface{}) error {
-	contextJSON, err := json.Marshal(contextData)
+// contextPayload returns the namespace and the JSON payload of a context
+// comment, decrypting it if necessary.
+func (t *TaskManager) contextPayload(comment *Comment) (string, []byte, error) {
+	namespace, encrypted, payload, _ := parseContextHeader(comment.Content)
+	if !encrypted {
+		return namespace, []byte(payload), nil
+	}
+	contextJSON, err := t.decryptContext(commentOwnerID(*comment), namespace, payload)
+	return namespace, contextJSON, err
+}
+
+// contextRevision returns the revision of a context comment without
+// decoding its data, 0 if there is none or it cannot be read.
+func (t *TaskManager) contextRevision(comment *Comment) int {
+	if comment == nil {
+		return 0
+	}
+	_, contextJSON, err := t.contextPayload(comment)
 	if err != nil {
-		return fmt.Errorf("failed to marshal context: %w", err)
+		return 0
+	}
+	var revision struct {
+		Rev float64 `json:"_rev"`
+	}
+	json.Unmarshal(contextJSON, &revision)
+	return int(revision.Rev)
+}
+
+// payload returns the stored JSON of the context, nil if there is none.
+func (c *Context) payload() ([]byte, error) {
+	if contexts := c.owner.preloaded(); contexts != nil {
+		return contexts[c.namespace].payload, nil
+	}
+	comment, err := c.getComment()
+	if err != nil {
+		return nil, err
 	}
+	_, _, contextJSON, err := c.manager.decodeContext(comment)
+	return contextJSON, err
+}
+
+// Get retrieves the context data.
+func (c *Context) Get() (map[string]interface{}, error) {
+	contextData, _, err := c.GetRevision()
+	return contextData, err
+}
 
-	content := fmt.Sprintf("%s %s", ContextPrefix, string(contextJSON))
+// GetRevision retrieves the context data along with its revision, for use
+// with CompareAndSet.
+func (c *Context) GetRevision() (map[string]interface{}, int, error) {
+	if contextData, rev, ok := c.cached(); ok {
+		return contextData, rev, nil
+	}
+	comment, err := c.getComment()
+	if err != nil {
+		return nil, 0, err
+	}
+	return c.manager.parseContext(comment)
+}
 
-	existingComment, err := t.getContextComment()
+// CompareAndSet replaces the context data if it is still at revision rev,
+// and returns ErrContextConflict otherwise. Stored data that cannot be
+// parsed is at revision 0 and can be replaced this way.
+func (c *Context) CompareAndSet(contextData map[string]interface{}, rev int) error {
+	comment, err := c.fetchComment()
 	if err != nil {
 		return err
 	}
+	if current := c.manager.contextRevision(comment); current != rev {
+		return fmt.Errorf("%w: expected revision %d, found %d", ErrContextConflict, rev, current)
+	}
+	if err := c.manager.validateContext(c.namespace, contextData); err != nil {
+		return err
+	}
+	if err := c.write(comment, contextData, rev+1); err != nil {
+		return err
+	}
+	c.record(comment, contextData, rev+1)
+	return nil
+}
 
-	if existingComment != nil {
-		return t.manager.api.UpdateComment(existingComment.ID, content)
+// write stores contextData at revision rev, replacing comment if it is not
+// nil. The write is read back to detect concurrent writers that started
+// from the same revision.
+func (c *Context) write(comment *Comment, contextData map[string]interface{}, rev int) error {
+	payload := make(map[string]interface{}, len(contextData)+1)
+	for key, value := range contextData {
+		payload[key] = value
 	}
+	payload[RevisionKey] = rev
+	c.invalidate()
 
-	_, err = t.manager.api.CreateComment(t.ID, content)
-	return err
-}
+	contextJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal context: %w", err)
+	}
 
-// UpdateContext updates specific fields in the context without replacing everything
-func (t *Task) UpdateContext(updates map[string]interface{}) error {
-	currentContext, err := t.GetContext()
+	content, err := c.manager.encodeContext(c.owner.ownerID(), c.namespace, contextJSON)
 	if err != nil {
 		return err
 	}
 
-	for key, value := range updates {
-		currentContext[key] = value
+	var written Comment
+	if comment != nil {
+		if err := c.owner.updateComment(*comment, content); err != nil {
+			return err
+		}
+		written = *comment
+		written.Content = content
+	} else {
+		created, err := c.owner.createComment(content)
+		if err != nil {
+			return err
+		}
+		written = *created
 	}
 
-	return t.SetContext(currentContext)
+	stored, err := c.fetchComment()
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.ID != written.ID {
+		// Someone else created a context comment first, ours is redundant.
+		if comment == nil {
+			c.owner.deleteComment(written)
+		}
+		return ErrContextConflict
+	}
+	if stored.Content != content {
+		return ErrContextConflict
+	}
+	return nil
 }
 
-// DeleteContext removes the context comment entirely
-func (t *Task) DeleteContext() error {
-	comment, err := t.getContextComment()
+// modify applies change to the current context data and writes the result,
+// retrying with fresh data on conflicts. If change returns nil the context
+// comment is deleted.
+func (c *Context) modify(change func(map[string]interface{}) map[string]interface{}) error {
+	return c.retry(func() error {
+		comment, err := c.fetchComment()
+		if err != nil {
+			return err
+		}
+		contextData, rev, err := c.manager.parseContext(comment)
+		if err != nil {
+			return err
+		}
+
+		contextData = change(contextData)
+		if contextData == nil {
+			if comment == nil {
+				return nil
+			}
+			if err := c.deleteComment(comment); err != nil {
+				return err
+			}
+			c.record(comment, nil, 0)
+			return nil
+		}
+
+		if err := c.manager.validateContext(c.namespace, contextData); err != nil {
+			return err
+		}
+		if err := c.write(comment, contextData, rev+1); err != nil {
+			return err
+		}
+		c.record(comment, contextData, rev+1)
+		return nil
+	})
+}
+
+// retry runs attempt until it does not return ErrContextConflict, at most
+// MaxContextRetries times.
+func (c *Context) retry(attempt func() error) error {
+	for i := 0; i < MaxContextRetries; i++ {
+		err := attempt()
+		if !errors.Is(err, ErrContextConflict) {
+			return err
+		}
+		c.manager.api.logger.Debug("context write conflicted, retrying",
+			"owner", c.owner.ownerID(), "namespace", c.namespace, "attempt", i+1)
+	}
+	return fmt.Errorf("%w: giving up after %d attempts", ErrContextConflict, MaxContextRetries)
+}
+
+// Set replaces the context data. The stored data is not parsed, so Set
+// also repairs contexts that are malformed or cannot be decrypted.
+func (c *Context) Set(contextData map[string]interface{}) error {
+	if contextData == nil {
+		return c.Delete()
+	}
+	if err := c.manager.validateContext(c.namespace, contextData); err != nil {
+		return err
+	}
+	return c.retry(func() error {
+		comment, err := c.fetchComment()
+		if err != nil {
+			return err
+		}
+		rev := c.manager.contextRevision(comment)
+		if err := c.write(comment, contextData, rev+1); err != nil {
+			return err
+		}
+		c.record(comment, contextData, rev+1)
+		return nil
+	})
+}
+
+// Update changes specific fields without replacing everything. The update
+// is retried on the latest context if someone else wrote in between.
+func (c *Context) Update(updates map[string]interface{}) error {
+	return c.modify(func(currentContext map[string]interface{}) map[string]interface{} {
+		for key, value := range updates {
+			currentContext[key] = value
+		}
+		return currentContext
+	})
+}
+
+// Delete removes the context comment entirely.
+func (c *Context) Delete() error {
+	comment, err := c.getComment()
 	if err != nil {
 		return err
 	}
@@ -89,22 +478,172 @@ func (t *Task) DeleteContext() error {
 	if comment == nil {
 		return nil
 	}
+	if err := c.deleteComment(comment); err != nil {
+		return err
+	}
+	c.record(comment, nil, 0)
+	return nil
+}
+
+func (c *Context) deleteComment(comment *Comment) error {
+	if err := c.owner.deleteComment(*comment); err != nil {
+		return err
+	}
+	c.uncache()
+	c.invalidate()
+	return nil
+}
+
+// DeleteField removes a specific field, and the whole context comment if
+// no other field is left.
+func (c *Context) DeleteField(key string) error {
+	return c.modify(func(currentContext map[string]interface{}) map[string]interface{} {
+		delete(currentContext, key)
+		if len(currentContext) == 0 {
+			return nil
+		}
+		return currentContext
+	})
+}
+
+// GetContext retrieves the context data for a task
+func (t *Task) GetContext() (map[string]interface{}, error) {
+	return t.Context("").Get()
+}
+
+// GetContextRevision retrieves the context data for a task along with its
+// revision, for use with CompareAndSetContext.
+func (t *Task) GetContextRevision() (map[string]interface{}, int, error) {
+	return t.Context("").GetRevision()
+}
+
+// CompareAndSetContext replaces the context data of a task if it is still
+// at revision rev, and returns ErrContextConflict otherwise.
+func (t *Task) CompareAndSetContext(contextData map[string]interface{}, rev int) error {
+	return t.Context("").CompareAndSet(contextData, rev)
+}
 
-	return t.manager.api.DeleteComment(comment.ID)
+// SetContext sets or updates the context data for a task
+func (t *Task) SetContext(contextData map[string]interface{}) error {
+	return t.Context("").Set(contextData)
+}
+
+// UpdateContext updates specific fields in the context without replacing
+// everything. The update is retried on the latest context if someone else
+// wrote in between.
+func (t *Task) UpdateContext(updates map[string]interface{}) error {
+	return t.Context("").Update(updates)
+}
+
+// DeleteContext removes the context comment entirely
+func (t *Task) DeleteContext() error {
+	return t.Context("").Delete()
 }
 
-// DeleteContextField removes a specific field from the context
+// DeleteContextField removes a specific field from the context, and the
+// whole context comment if no other field is left.
 func (t *Task) DeleteContextField(key string) error {
-	currentContext, err := t.GetContext()
+	return t.Context("").DeleteField(key)
+}
+
+// GetContextAs decodes the context data of a task into a value of type T,
+// typically a struct with json tags. Fields not known to T are ignored.
+// The stored JSON is decoded directly, so large integers keep their
+// precision.
+func GetContextAs[T any](task *Task) (T, error) {
+	var value T
+	contextJSON, err := task.Context("").payload()
+	if err != nil || contextJSON == nil {
+		return value, err
+	}
+	// Drop the revision while keeping the other values as stored.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(contextJSON, &fields); err != nil {
+		return value, fmt.Errorf("failed to decode context: %w", err)
+	}
+	delete(fields, RevisionKey)
+	if contextJSON, err = json.Marshal(fields); err != nil {
+		return value, fmt.Errorf("failed to decode context: %w", err)
+	}
+	if err := json.Unmarshal(contextJSON, &value); err != nil {
+		return value, fmt.Errorf("failed to decode context: %w", err)
+	}
+	return value, nil
+}
+
+// SetContextFrom stores value, typically a struct with json tags, as the
+// context data of a task. The fields of T replace the stored ones, so
+// fields omitted by omitempty are removed. Fields of the stored context
+// that T does not know are kept, so several tools can share one context
+// comment.
+func SetContextFrom[T any](task *Task, value T) error {
+	contextJSON, err := json.Marshal(value)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to marshal context: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(contextJSON))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil || fields == nil {
+		return fmt.Errorf("context must encode as a JSON object: %s", contextJSON)
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(value))
+	return task.Context("").modify(func(currentContext map[string]interface{}) map[string]interface{} {
+		for _, key := range known {
+			delete(currentContext, key)
+		}
+		for key, value := range fields {
+			currentContext[key] = value
+		}
+		return currentContext
+	})
+}
+
+// jsonFieldNames returns the top-level keys encoding/json may encode for
+// a struct type, nil for other types.
+func jsonFieldNames(typ reflect.Type) []string {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil
 	}
 
-	delete(currentContext, key)
+	var names []string
+	for _, field := range reflect.VisibleFields(typ) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() || nestedInJSON(typ, field.Index) {
+			continue
+		}
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			// Its fields are promoted and listed on their own.
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
 
-	if len(currentContext) == 0 {
-		return t.DeleteContext()
+// nestedInJSON reports whether a promoted field is encoded inside an
+// embedded struct that has a JSON name or is ignored, rather than at the
+// top level.
+func nestedInJSON(typ reflect.Type, index []int) bool {
+	for i := 1; i < len(index); i++ {
+		embedded := typ.FieldByIndex(index[:i])
+		if name, _, _ := strings.Cut(embedded.Tag.Get("json"), ","); name != "" {
+			return true
+		}
 	}
+	return false
+}
 
-	return t.SetContext(currentContext)
+func indirect(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Pointer {
+		return typ.Elem()
+	}
+	return typ
 }
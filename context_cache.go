@@ -0,0 +1,129 @@
+package godoist
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// cachedContext is a parsed context preloaded by TaskManager.LoadContexts.
+type cachedContext struct {
+	data map[string]interface{}
+	rev  int
+	// payload is the stored JSON, see GetContextAs.
+	payload []byte
+}
+
+// ContextSyncThreshold is the number of tasks from which LoadContexts
+// fetches comments with a single Sync API request rather than one request
+// per task.
+var ContextSyncThreshold = 20
+
+// LoadContexts fetches the contexts of all namespaces of tasks. For fewer
+// than ContextSyncThreshold tasks the comments of every task are
+// requested; for more, the comments of the whole account are downloaded
+// with a single Sync API request, which can be large. With a ContextStore
+// other than CommentContextStore the store is asked instead. The parsed
+// contexts are cached on the tasks until they are written through this
+// client; contexts that fail to parse are reported and left unloaded.
+func (t *TaskManager) LoadContexts(tasks []*Task) error {
+	comments, err := t.contextComments(tasks)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, task := range tasks {
+		contexts := make(map[string]cachedContext)
+		failed := false
+		for _, comment := range comments[task.ID] {
+			namespace, ok := contextNamespace(comment.Content)
+			if _, seen := contexts[namespace]; !ok || seen {
+				continue
+			}
+			data, rev, payload, err := t.decodeContext(&comment)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("task %s: %w", task.ID, err))
+				failed = true
+				break
+			}
+			contexts[namespace] = cachedContext{data: data, rev: rev, payload: payload}
+			t.cacheContextComment(comment)
+		}
+		if !failed {
+			task.contexts = contexts
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// contextComments returns the comments of tasks by task ID, oldest first.
+func (t *TaskManager) contextComments(tasks []*Task) (map[string][]Comment, error) {
+	comments := make(map[string][]Comment)
+	if _, ok := t.contextStore.(CommentContextStore); !ok || len(tasks) < ContextSyncThreshold {
+		for _, task := range tasks {
+			taskComments, err := t.contextStore.Comments(task)
+			if err != nil {
+				return nil, fmt.Errorf("task %s: %w", task.ID, err)
+			}
+			comments[task.ID] = taskComments
+		}
+		return comments, nil
+	}
+
+	resp, err := t.api.SyncResources([]string{"notes"})
+	if err != nil {
+		return nil, err
+	}
+	notes := slices.Clone(resp.Notes)
+	// The oldest context comment of a namespace wins, as with GetComments.
+	slices.SortFunc(notes, func(a, b Note) int {
+		return cmp.Or(cmp.Compare(a.PostedAt, b.PostedAt), cmp.Compare(a.ID, b.ID))
+	})
+	for _, note := range notes {
+		if !note.IsDeleted {
+			comments[note.ItemID] = append(comments[note.ItemID], note.Comment())
+		}
+	}
+	return comments, nil
+}
+
+// cached returns the preloaded context, if the contexts of the task have
+// been loaded.
+func (c *Context) cached() (map[string]interface{}, int, bool) {
+	contexts := c.owner.preloaded()
+	if contexts == nil {
+		return nil, 0, false
+	}
+	cached, ok := contexts[c.namespace]
+	if !ok {
+		return make(map[string]interface{}), 0, true
+	}
+	return copyValue(cached.data).(map[string]interface{}), cached.rev, true
+}
+
+// invalidate drops the preloaded contexts of the owner after a write.
+func (c *Context) invalidate() {
+	c.owner.invalidate()
+}
+
+// copyValue deep copies decoded JSON so callers cannot modify the cache.
+func copyValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			copied[key] = copyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,200 @@
+package godoist
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EncryptedContextPrefix marks encrypted context comments. Namespaced ones
+// use "[CONTEXT-ENC:namespace]".
+const EncryptedContextPrefix = "[CONTEXT-ENC]"
+
+// ErrNoContextKey is returned when reading an encrypted context without a
+// key provider, or with none of the keys it was encrypted with.
+var ErrNoContextKey = errors.New("no key to decrypt context")
+
+// KeyProvider supplies the AES keys used to encrypt contexts. Keys must be
+// 16, 24 or 32 bytes long.
+type KeyProvider interface {
+	// CurrentKey returns the key new contexts are encrypted with and its ID,
+	// which is stored alongside the ciphertext.
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key with the given ID, or ErrNoContextKey.
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeys is a KeyProvider over a fixed list of keys. The first key
+// encrypts, all of them decrypt, so keys are rotated by prepending a new
+// one and keeping the old ones until RotateContextKeys has run.
+type StaticKeys [][]byte
+
+// NewStaticKeys decodes base64 encoded keys into StaticKeys.
+func NewStaticKeys(encoded ...string) (StaticKeys, error) {
+	keys := make(StaticKeys, 0, len(encoded))
+	for i, value := range encoded {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("context key %d: %w", i, err)
+		}
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("context key %d: %w", i, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// keyID identifies a key without revealing it.
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+func (k StaticKeys) CurrentKey() (string, []byte, error) {
+	if len(k) == 0 {
+		return "", nil, ErrNoContextKey
+	}
+	return keyID(k[0]), k[0], nil
+}
+
+func (k StaticKeys) Key(id string) ([]byte, error) {
+	for _, key := range k {
+		if keyID(key) == id {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown key %s", ErrNoContextKey, id)
+}
+
+// failingKeys reports a configuration error whenever keys are needed, so a
+// broken key setup never falls back to plaintext.
+type failingKeys struct{ err error }
+
+func (k failingKeys) CurrentKey() (string, []byte, error) { return "", nil, k.err }
+func (k failingKeys) Key(string) ([]byte, error)          { return nil, k.err }
+
+// EncryptContexts makes context writes encrypt their data with AES-GCM
+// using keys. Plain contexts can still be read and are encrypted on their
+// next write. A nil provider disables encryption.
+func (t *Todoist) EncryptContexts(keys KeyProvider) {
+	t.Tasks.keys = keys
+}
+
+// encodeContext builds the content of a context comment, encrypting
+// contextJSON if a key provider is configured.
+func (t *TaskManager) encodeContext(ownerID, namespace string, contextJSON []byte) (string, error) {
+	if t.keys == nil {
+		return fmt.Sprintf("%s %s", contextPrefix(namespace), string(contextJSON)), nil
+	}
+
+	id, key, err := t.keys.CurrentKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt context: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt context: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to encrypt context: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, contextJSON, contextAAD(ownerID, namespace))
+	return fmt.Sprintf("%s %s:%s", encryptedContextPrefix(namespace), id, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// decryptContext decrypts the payload of an encrypted context comment.
+func (t *TaskManager) decryptContext(ownerID, namespace, payload string) ([]byte, error) {
+	if t.keys == nil {
+		return nil, ErrNoContextKey
+	}
+	id, encoded, ok := strings.Cut(payload, ":")
+	if !ok {
+		return nil, errors.New("missing key ID")
+	}
+	key, err := t.keys.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, contextAAD(ownerID, namespace))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// contextAAD binds a ciphertext to its owner and namespace, so it cannot be
+// copied to another task or project unnoticed.
+func contextAAD(ownerID, namespace string) []byte {
+	return []byte(ownerID + "\x00" + namespace)
+}
+
+func encryptedContextPrefix(namespace string) string {
+	if namespace == "" {
+		return EncryptedContextPrefix
+	}
+	return "[CONTEXT-ENC:" + namespace + "]"
+}
+
+// needsRotation reports whether a context comment is stored in plain text
+// or with a key other than the current one.
+func needsRotation(content, currentID string) bool {
+	_, encrypted, payload, _ := parseContextHeader(content)
+	id, _, _ := strings.Cut(payload, ":")
+	return !encrypted || id != currentID
+}
+
+// RotateContextKeys re-encrypts the contexts of tasks that are stored in
+// plain text or with an old key, using the current key.
+func (t *TaskManager) RotateContextKeys(tasks []*Task) error {
+	if t.keys == nil {
+		return ErrNoContextKey
+	}
+	currentID, _, err := t.keys.CurrentKey()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, task := range tasks {
+		comments, err := taskOwner{task}.comments()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("task %s: %w", task.ID, err))
+			continue
+		}
+		for _, comment := range comments {
+			namespace, ok := contextNamespace(comment.Content)
+			if !ok || !needsRotation(comment.Content, currentID) {
+				continue
+			}
+			rewrite := func(contextData map[string]interface{}) map[string]interface{} { return contextData }
+			if err := task.Context(namespace).modify(rewrite); err != nil {
+				errs = append(errs, fmt.Errorf("task %s: %w", task.ID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,221 @@
+package godoist
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// historyNamespacePrefix marks the namespaces holding context history.
+// Such namespaces are reserved and not listed by Namespaces.
+const historyNamespacePrefix = "_history"
+
+// DefaultHistoryLimit is the number of changes kept per context if
+// EnableContextHistory is given no limit.
+const DefaultHistoryLimit = 50
+
+// MaxContextHistorySize is the maximum size in bytes of the encoded history
+// of a context. The oldest changes are dropped to stay below it, so that
+// the history fits into a single comment.
+var MaxContextHistorySize = 8 << 10
+
+// historyConfig configures the recording of context changes.
+type historyConfig struct {
+	author string
+	limit  int
+}
+
+// ContextChange is a recorded write to a context.
+type ContextChange struct {
+	// Revision is the revision the write produced, 0 if it deleted the
+	// context.
+	Revision int       `json:"rev"`
+	At       time.Time `json:"at"`
+	By       string    `json:"by,omitempty"`
+	// Changed lists the added, modified and removed keys.
+	Changed []string `json:"changed"`
+	// Old holds the previous values of the changed keys that existed.
+	Old map[string]interface{} `json:"old,omitempty"`
+	// OldRaw holds the previous content of the context comment if it could
+	// not be parsed. Old is empty then.
+	OldRaw string `json:"old_raw,omitempty"`
+}
+
+// ContextRevision is a past state of a context.
+type ContextRevision struct {
+	ContextChange
+	// Data is the context data right after the change. It is nil if it
+	// cannot be reconstructed because a later change replaced a context
+	// that could not be parsed.
+	Data map[string]interface{}
+}
+
+// EnableContextHistory records every context write made by this client in
+// a separate history context, so past revisions can be inspected with
+// ContextHistory. author identifies this client in the history, limit is
+// the number of changes kept per context, see also MaxContextHistorySize.
+//
+// Recording costs about three requests per write, as the history context
+// is read, written and read back like any other context.
+func (t *Todoist) EnableContextHistory(author string, limit int) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	t.Tasks.history = &historyConfig{author: author, limit: limit}
+}
+
+// DisableContextHistory stops recording context writes. Recorded history
+// is kept.
+func (t *Todoist) DisableContextHistory() {
+	t.Tasks.history = nil
+}
+
+func historyNamespace(namespace string) string {
+	if namespace == "" {
+		return historyNamespacePrefix
+	}
+	return historyNamespacePrefix + "." + namespace
+}
+
+func isHistoryNamespace(namespace string) bool {
+	return namespace == historyNamespacePrefix || strings.HasPrefix(namespace, historyNamespacePrefix+".")
+}
+
+func (c *Context) historyContext() *Context {
+	return &Context{manager: c.manager, owner: c.owner, namespace: historyNamespace(c.namespace)}
+}
+
+// diffContext returns the keys that differ between before and after.
+func diffContext(before, after map[string]interface{}) []string {
+	var changed []string
+	for key, value := range before {
+		if other, ok := after[key]; !ok || !reflect.DeepEqual(value, other) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// record appends a write replacing previous to the history if history is
+// enabled. Failing to record does not fail the write, which already
+// happened.
+func (c *Context) record(previous *Comment, after map[string]interface{}, rev int) {
+	history := c.manager.history
+	if history == nil || isHistoryNamespace(c.namespace) {
+		return
+	}
+
+	before, _, err := c.manager.parseContext(previous)
+	raw := ""
+	if err != nil {
+		before, raw = nil, previous.Content
+	}
+	// Compare the data as stored, i.e. decoded from JSON.
+	var stored map[string]interface{}
+	if afterJSON, err := json.Marshal(after); err == nil {
+		json.Unmarshal(afterJSON, &stored)
+	}
+	changed := diffContext(before, stored)
+	if len(changed) == 0 && raw == "" {
+		return
+	}
+	change := ContextChange{Revision: rev, At: time.Now().UTC(), By: history.author, Changed: changed, OldRaw: raw}
+	for _, key := range changed {
+		if value, ok := before[key]; ok {
+			if change.Old == nil {
+				change.Old = make(map[string]interface{})
+			}
+			change.Old[key] = value
+		}
+	}
+
+	err = c.historyContext().modify(func(data map[string]interface{}) map[string]interface{} {
+		changes := append(decodeChanges(data), change)
+		if len(changes) > history.limit {
+			changes = changes[len(changes)-history.limit:]
+		}
+		return map[string]interface{}{"changes": trimChanges(changes)}
+	})
+	if err != nil {
+		c.manager.api.logger.Warn("failed to record context history",
+			"owner", c.owner.ownerID(), "namespace", c.namespace, "error", err)
+	}
+}
+
+// trimChanges drops the oldest changes until the encoded changes fit into
+// MaxContextHistorySize. The latest change is always kept.
+func trimChanges(changes []ContextChange) []ContextChange {
+	for len(changes) > 1 {
+		encoded, err := json.Marshal(changes)
+		if err != nil || len(encoded) <= MaxContextHistorySize {
+			break
+		}
+		changes = changes[1:]
+	}
+	return changes
+}
+
+func decodeChanges(data map[string]interface{}) []ContextChange {
+	var changes []ContextChange
+	if encoded, err := json.Marshal(data["changes"]); err == nil {
+		json.Unmarshal(encoded, &changes)
+	}
+	return changes
+}
+
+// History returns the recorded revisions of the context, newest first.
+// The data of each revision is reconstructed from the current data, so
+// writes made without history enabled are not reflected.
+func (c *Context) History() ([]ContextRevision, error) {
+	current, err := c.Get()
+	if err != nil {
+		return nil, err
+	}
+	historyData, err := c.historyContext().Get()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := decodeChanges(historyData)
+	revisions := make([]ContextRevision, 0, len(changes))
+	data := current
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		revisions = append(revisions, ContextRevision{ContextChange: change, Data: data})
+		if data == nil || change.OldRaw != "" {
+			data = nil
+			continue
+		}
+
+		previous := copyValue(data).(map[string]interface{})
+		for _, key := range change.Changed {
+			if value, ok := change.Old[key]; ok {
+				previous[key] = value
+			} else {
+				delete(previous, key)
+			}
+		}
+		data = previous
+	}
+	return revisions, nil
+}
+
+// ContextHistory returns the recorded revisions of the default context of
+// the task, newest first. See Todoist.EnableContextHistory.
+func (t *Task) ContextHistory() ([]ContextRevision, error) {
+	return t.Context("").History()
+}
+
+// ContextHistory returns the recorded revisions of the default context of
+// the project, newest first. See Todoist.EnableContextHistory.
+func (p *Project) ContextHistory() ([]ContextRevision, error) {
+	return p.Context("").History()
+}
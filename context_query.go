@@ -0,0 +1,418 @@
+package godoist
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ContextQuery is a parsed expression over task contexts, for example
+//
+//	context.urgency == "high" && context.retries > 3
+//
+// Paths starting with "context" read the default context, paths starting
+// with "contexts.<namespace>" the context of a namespace, and nested
+// objects are entered with further dots. Values are compared with ==, !=,
+// <, <=, > and >=, combined with &&, || and !, and grouped with
+// parentheses. Literals are JSON strings, numbers, true, false and null.
+// A path on its own is true if the value exists and is not false, null, 0
+// or "". Missing values are null.
+type ContextQuery struct {
+	source string
+	root   queryNode
+}
+
+// queryNode evaluates to a decoded JSON value given the contexts of a task
+// by namespace.
+type queryNode func(contexts map[string]cachedContext) interface{}
+
+// ParseContextQuery parses a context query expression.
+func ParseContextQuery(expr string) (*ContextQuery, error) {
+	tokens, err := lexQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.peek().text, p.peek().pos)
+	}
+	return &ContextQuery{source: expr, root: root}, nil
+}
+
+func (q *ContextQuery) String() string {
+	return q.source
+}
+
+// Match reports whether the contexts of task satisfy the query. Only
+// contexts preloaded with TaskManager.LoadContexts are considered, tasks
+// without them never match.
+func (q *ContextQuery) Match(task *Task) bool {
+	if task.contexts == nil {
+		return false
+	}
+	return truthy(q.root(task.contexts))
+}
+
+// QueryContext returns the tasks whose preloaded contexts match expr, see
+// ContextQuery.
+func (t *TaskManager) QueryContext(expr string) ([]*Task, error) {
+	query, err := ParseContextQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return t.filter(query.Match), nil
+}
+
+// WhereContext returns the tasks whose preloaded default context has value
+// at key. Nested values are addressed with dots, e.g. "build.status".
+func (t *TaskManager) WhereContext(key string, value interface{}) []*Task {
+	path := strings.Split(key, ".")
+	expected := normalizeJSON(value)
+	return t.filter(func(task *Task) bool {
+		if task.contexts == nil {
+			return false
+		}
+		return reflect.DeepEqual(lookupPath(task.contexts, "", path), expected)
+	})
+}
+
+func (t *TaskManager) filter(keep func(*Task) bool) []*Task {
+	var tasks []*Task
+	for _, task := range t.All() {
+		if keep(task) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// normalizeJSON converts value to what decoding its JSON encoding yields,
+// so that e.g. int 3 equals a decoded 3.0.
+func normalizeJSON(value interface{}) interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+func lookupPath(contexts map[string]cachedContext, namespace string, path []string) interface{} {
+	var value interface{} = contexts[namespace].data
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func truthy(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case float64:
+		return value != 0
+	case string:
+		return value != ""
+	default:
+		return true
+	}
+}
+
+// compareValues orders two numbers or two strings. ok is false for values
+// that cannot be ordered.
+func compareValues(a, b interface{}) (result int, ok bool) {
+	switch a := a.(type) {
+	case float64:
+		if b, isNumber := b.(float64); isNumber {
+			return compareOrdered(a, b), true
+		}
+	case string:
+		if b, isString := b.(string); isString {
+			return compareOrdered(a, b), true
+		}
+	}
+	return 0, false
+}
+
+func compareOrdered[T float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+type queryToken struct {
+	kind string // "op", "ident", "string", "number" or "eof"
+	text string
+	pos  int
+}
+
+// lexQuery splits a query expression into tokens. Offsets are in bytes.
+func lexQuery(expr string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(expr); {
+		c, width := utf8.DecodeRuneInString(expr[i:])
+		switch {
+		case c == utf8.RuneError && width == 1:
+			return nil, fmt.Errorf("invalid UTF-8 at offset %d", i)
+		case unicode.IsSpace(c):
+			i += width
+		case strings.ContainsRune("()", c):
+			tokens = append(tokens, queryToken{"op", string(c), i})
+			i++
+		case strings.ContainsRune("=!<>&|", c):
+			op := string(c)
+			if i+1 < len(expr) {
+				switch pair := expr[i : i+2]; pair {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					op = pair
+				}
+			}
+			if op == "=" || op == "&" || op == "|" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", op, i)
+			}
+			tokens = append(tokens, queryToken{"op", op, i})
+			i += len(op)
+		case c == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, queryToken{"string", expr[i : end+1], i})
+			i = end + 1
+		case c == '-' || unicode.IsDigit(c):
+			end := i + 1
+			for end < len(expr) && strings.ContainsRune("0123456789.eE+-", rune(expr[end])) {
+				end++
+			}
+			tokens = append(tokens, queryToken{"number", expr[i:end], i})
+			i = end
+		case c == '_' || unicode.IsLetter(c):
+			end := i + width
+			for end < len(expr) {
+				r, w := utf8.DecodeRuneInString(expr[end:])
+				if r != '_' && r != '.' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				end += w
+			}
+			tokens = append(tokens, queryToken{"ident", expr[i:end], i})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+		}
+	}
+	return append(tokens, queryToken{"eof", "end of query", len(expr)}), nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) done() bool {
+	return p.peek().kind == "eof"
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *queryParser) accept(op string) bool {
+	if token := p.peek(); token.kind == "op" && token.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(contexts map[string]cachedContext) interface{} {
+			return truthy(l(contexts)) || truthy(right(contexts))
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(contexts map[string]cachedContext) interface{} {
+			return truthy(l(contexts)) && truthy(right(contexts))
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(contexts map[string]cachedContext) interface{} {
+			return !truthy(operand(contexts))
+		}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (queryNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.peek().text
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.pos++
+	default:
+		return left, nil
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(contexts map[string]cachedContext) interface{} {
+		a, b := left(contexts), right(contexts)
+		switch op {
+		case "==":
+			return reflect.DeepEqual(a, b)
+		case "!=":
+			return !reflect.DeepEqual(a, b)
+		}
+		c, ok := compareValues(a, b)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	}, nil
+}
+
+func (p *queryParser) parseOperand() (queryNode, error) {
+	token := p.peek()
+	p.pos++
+	switch token.kind {
+	case "op":
+		if token.text != "(" {
+			break
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("expected ) at offset %d", p.peek().pos)
+		}
+		return inner, nil
+	case "string":
+		value, err := strconv.Unquote(token.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string at offset %d: %w", token.pos, err)
+		}
+		return constant(value), nil
+	case "number":
+		value, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number at offset %d: %w", token.pos, err)
+		}
+		return constant(value), nil
+	case "ident":
+		return parsePath(token)
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", token.text, token.pos)
+}
+
+func constant(value interface{}) queryNode {
+	return func(map[string]cachedContext) interface{} { return value }
+}
+
+// parsePath turns an identifier into a literal or a context lookup.
+func parsePath(token queryToken) (queryNode, error) {
+	switch token.text {
+	case "true":
+		return constant(true), nil
+	case "false":
+		return constant(false), nil
+	case "null":
+		return constant(nil), nil
+	}
+
+	parts := strings.Split(token.text, ".")
+	namespace := ""
+	switch {
+	case parts[0] == "context" && len(parts) > 1:
+		parts = parts[1:]
+	case parts[0] == "contexts" && len(parts) > 2:
+		namespace, parts = parts[1], parts[2:]
+	default:
+		return nil, fmt.Errorf("unknown path %q at offset %d, expected context.<key> or contexts.<namespace>.<key>", token.text, token.pos)
+	}
+	if validateNamespace(namespace) != nil || isHistoryNamespace(namespace) {
+		return nil, fmt.Errorf("invalid namespace %q at offset %d", namespace, token.pos)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("empty key in path %q at offset %d", token.text, token.pos)
+		}
+	}
+	return func(contexts map[string]cachedContext) interface{} {
+		return lookupPath(contexts, namespace, parts)
+	}, nil
+}
@@ -0,0 +1,240 @@
+package godoist
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ContextStore persists the context of tasks. Contexts are exchanged as
+// comments whose content starts with the context prefix of their
+// namespace, e.g. "[CONTEXT:ci] {...}", whatever the storage looks like.
+type ContextStore interface {
+	// Comments returns the context comments of a task. Other comments may
+	// be included and are ignored.
+	Comments(task *Task) ([]Comment, error)
+	Create(task *Task, content string) (*Comment, error)
+	Update(task *Task, comment Comment, content string) error
+	Delete(task *Task, comment Comment) error
+}
+
+// CommentContextStore stores contexts as task comments. It is the default.
+type CommentContextStore struct{}
+
+func (CommentContextStore) Comments(task *Task) ([]Comment, error) {
+	return task.manager.api.GetComments(task.ID)
+}
+
+func (CommentContextStore) Create(task *Task, content string) (*Comment, error) {
+	comment, err := task.manager.api.CreateComment(task.ID, content)
+	if err != nil {
+		return nil, err
+	}
+	task.NoteCount++
+	return comment, nil
+}
+
+func (CommentContextStore) Update(task *Task, comment Comment, content string) error {
+	return task.manager.api.UpdateComment(comment.ID, content)
+}
+
+func (CommentContextStore) Delete(task *Task, comment Comment) error {
+	if err := task.manager.api.DeleteComment(comment.ID); err != nil {
+		return err
+	}
+	task.NoteCount--
+	return nil
+}
+
+// DescriptionContextStore stores contexts as fenced JSON blocks at the end
+// of the task description, one per namespace:
+//
+//	```context:ci
+//	{"_rev":1,"status":"green"}
+//	```
+//
+// Reads use the cached description and cost no request, which also means
+// conflicts are only detected against the last sync.
+//
+// While this store is in use, TaskPatch.Description and Task.Update keep
+// the context blocks of the task, whatever description they are given.
+// Descriptions passed to CreateWith or AddTask are stored as is: context
+// blocks they contain become the contexts of the new task.
+type DescriptionContextStore struct{}
+
+// contextBlock matches a fenced context block along with the blank lines
+// separating it from the text before, and captures its info string and
+// payload.
+var contextBlock = regexp.MustCompile("(?ms)\\n*^```(context(?:-enc)?(?::[^\\s\\[\\]]+)?)[ \\t]*\\n(.*?)\\n```[ \\t]*$")
+
+// descriptionCommentPrefix marks the IDs of contexts read from descriptions.
+const descriptionCommentPrefix = "description:"
+
+func blockInfo(namespace string, encrypted bool) string {
+	info := "context"
+	if encrypted {
+		info += "-enc"
+	}
+	if namespace != "" {
+		info += ":" + namespace
+	}
+	return info
+}
+
+func (DescriptionContextStore) Comments(task *Task) ([]Comment, error) {
+	var comments []Comment
+	for _, match := range contextBlock.FindAllStringSubmatch(task.Description, -1) {
+		info, payload := match[1], strings.TrimSpace(match[2])
+		rest, encrypted := strings.CutPrefix(strings.TrimPrefix(info, "context"), "-enc")
+		namespace := strings.TrimPrefix(rest, ":")
+
+		prefix := contextPrefix(namespace)
+		if encrypted {
+			prefix = encryptedContextPrefix(namespace)
+		}
+		comments = append(comments, Comment{
+			ID:      descriptionCommentPrefix + info,
+			TaskID:  task.ID,
+			Content: prefix + " " + payload,
+		})
+	}
+	return comments, nil
+}
+
+func (s DescriptionContextStore) Create(task *Task, content string) (*Comment, error) {
+	namespace, encrypted, payload, ok := parseContextHeader(content)
+	if !ok {
+		return nil, errors.New("not a context comment")
+	}
+	description := removeContextBlock(task.Description, namespace)
+	info := blockInfo(namespace, encrypted)
+	block := "```" + info + "\n" + payload + "\n```"
+	if description != "" {
+		block = "\n\n" + block
+	}
+	if err := task.Patch().rawDescription(description + block).Apply(); err != nil {
+		return nil, err
+	}
+	return &Comment{ID: descriptionCommentPrefix + info, TaskID: task.ID, Content: content}, nil
+}
+
+func (s DescriptionContextStore) Update(task *Task, comment Comment, content string) error {
+	_, err := s.Create(task, content)
+	return err
+}
+
+func (DescriptionContextStore) Delete(task *Task, comment Comment) error {
+	namespace, ok := contextNamespace(comment.Content)
+	if !ok {
+		return errors.New("not a context comment")
+	}
+	return task.Patch().rawDescription(removeContextBlock(task.Description, namespace)).Apply()
+}
+
+// removeContextBlock removes the context blocks of namespace, plain or
+// encrypted, from description.
+func removeContextBlock(description, namespace string) string {
+	description = contextBlock.ReplaceAllStringFunc(description, func(block string) string {
+		info := contextBlock.FindStringSubmatch(block)[1]
+		if info == blockInfo(namespace, false) || info == blockInfo(namespace, true) {
+			return ""
+		}
+		return block
+	})
+	return strings.TrimLeft(strings.TrimRight(description, " \t\n"), "\n")
+}
+
+// spliceContextBlocks replaces the context blocks of description with
+// those of previous. description is returned unchanged if previous has
+// none.
+func spliceContextBlocks(description, previous string) string {
+	blocks := contextBlock.FindAllString(previous, -1)
+	if len(blocks) == 0 {
+		return description
+	}
+	text := strings.TrimRight(stripContextBlocks(description), " \t\n")
+	for _, block := range blocks {
+		block = strings.TrimLeft(block, "\n")
+		if text != "" {
+			block = "\n\n" + block
+		}
+		text += block
+	}
+	return text
+}
+
+// stripContextBlocks returns description without any context blocks.
+func stripContextBlocks(description string) string {
+	return contextBlock.ReplaceAllString(description, "")
+}
+
+// UseContextStore selects where task contexts are stored. Contexts already
+// stored elsewhere can be moved with TaskManager.MigrateContexts.
+func (t *Todoist) UseContextStore(store ContextStore) {
+	if store == nil {
+		store = CommentContextStore{}
+	}
+	t.Tasks.contextStore = store
+}
+
+// MigrateContexts moves the contexts of tasks, all namespaces included,
+// from one store to another, replacing contexts the target already has.
+// Contexts are copied as is, so encrypted ones stay encrypted. Both stores
+// are read for every task, which costs a request each with comments, and
+// every namespace costs a write to the target and a delete from the
+// source. Migrating from CommentContextStore skips tasks without comments.
+func (t *TaskManager) MigrateContexts(tasks []*Task, from, to ContextStore) error {
+	_, fromComments := from.(CommentContextStore)
+	var errs []error
+	for _, task := range tasks {
+		if fromComments && task.NoteCount == 0 {
+			continue
+		}
+		if err := migrateContexts(task, from, to); err != nil {
+			errs = append(errs, fmt.Errorf("task %s: %w", task.ID, err))
+		}
+		task.contexts = nil
+		delete(t.contexts, task.ID)
+	}
+	return errors.Join(errs...)
+}
+
+func migrateContexts(task *Task, from, to ContextStore) error {
+	source, err := from.Comments(task)
+	if err != nil {
+		return err
+	}
+	target, err := to.Comments(task)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]Comment)
+	for _, comment := range target {
+		if namespace, ok := contextNamespace(comment.Content); ok {
+			existing[namespace] = comment
+		}
+	}
+
+	migrated := make(map[string]bool)
+	for _, comment := range source {
+		namespace, ok := contextNamespace(comment.Content)
+		if !ok || migrated[namespace] {
+			continue
+		}
+		migrated[namespace] = true
+
+		if old, exists := existing[namespace]; exists {
+			err = to.Update(task, old, comment.Content)
+		} else {
+			_, err = to.Create(task, comment.Content)
+		}
+		if err != nil {
+			return err
+		}
+		if err := from.Delete(task, comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
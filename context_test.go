@@ -0,0 +1,759 @@
+package godoist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// commentServer is an in-memory stand-in for the comments endpoints.
+type commentServer struct {
+	mu       sync.Mutex
+	comments map[string]*Comment
+	nextID   int
+	// gets counts the comment listings.
+	gets int
+	// mux serves the comments endpoints, tests can add more.
+	mux *http.ServeMux
+	// afterUpdate, if set, runs after a comment was updated and can
+	// simulate a concurrent writer.
+	afterUpdate func(comment *Comment)
+}
+
+// newCommentServer starts a comments API and points APIURL at it for the
+// duration of the test.
+func newCommentServer(t *testing.T) *commentServer {
+	t.Helper()
+	cs := &commentServer{comments: make(map[string]*Comment)}
+
+	mux := http.NewServeMux()
+	cs.mux = mux
+	mux.HandleFunc("GET /comments", func(w http.ResponseWriter, r *http.Request) {
+		cs.mu.Lock()
+		defer cs.mu.Unlock()
+		cs.gets++
+		results := []Comment{}
+		for i := 1; i <= cs.nextID; i++ {
+			if c, ok := cs.comments[fmt.Sprint(i)]; ok && c.TaskID == r.URL.Query().Get("task_id") && c.ProjectID == r.URL.Query().Get("project_id") {
+				results = append(results, *c)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": results, "next_cursor": nil})
+	})
+	mux.HandleFunc("POST /comments", func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &payload)
+		comment := cs.add(payload["task_id"], payload["content"])
+		if payload["project_id"] != "" {
+			cs.mu.Lock()
+			cs.comments[comment.ID].ProjectID = payload["project_id"]
+			comment = *cs.comments[comment.ID]
+			cs.mu.Unlock()
+		}
+		json.NewEncoder(w).Encode(comment)
+	})
+	mux.HandleFunc("POST /comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &payload)
+		cs.mu.Lock()
+		defer cs.mu.Unlock()
+		comment, ok := cs.comments[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		comment.Content = payload["content"]
+		if cs.afterUpdate != nil {
+			cs.afterUpdate(comment)
+		}
+		json.NewEncoder(w).Encode(comment)
+	})
+	mux.HandleFunc("DELETE /comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		cs.mu.Lock()
+		defer cs.mu.Unlock()
+		delete(cs.comments, r.PathValue("id"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	srv := httptest.NewServer(mux)
+	orig := APIURL
+	APIURL = srv.URL
+	t.Cleanup(func() {
+		APIURL = orig
+		srv.Close()
+	})
+	return cs
+}
+
+func (cs *commentServer) add(taskID, content string) Comment {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.nextID++
+	comment := &Comment{ID: fmt.Sprint(cs.nextID), TaskID: taskID, Content: content}
+	cs.comments[comment.ID] = comment
+	return *comment
+}
+
+func TestTypedContext(t *testing.T) {
+	cs := newCommentServer(t)
+	cs.add("1", ContextPrefix+` {"owner": "ci", "build": {"id": 7}}`)
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1", Content: "Deploy"}})
+	task := td.Tasks.Get("1")
+
+	type deployContext struct {
+		Attempts int    `json:"attempts"`
+		Stage    string `json:"stage,omitempty"`
+		BuildID  int64  `json:"build_id,omitempty"`
+	}
+
+	got, err := GetContextAs[deployContext](task)
+	if err != nil {
+		t.Fatalf("GetContextAs() returned error: %v", err)
+	}
+	if got != (deployContext{}) {
+		t.Errorf("expected zero context, got %+v", got)
+	}
+
+	if err := SetContextFrom(task, deployContext{Attempts: 2, Stage: "staging"}); err != nil {
+		t.Fatalf("SetContextFrom() returned error: %v", err)
+	}
+	got, err = GetContextAs[deployContext](task)
+	if err != nil || got.Attempts != 2 || got.Stage != "staging" {
+		t.Errorf("expected stored context, got %+v (%v)", got, err)
+	}
+
+	raw, _ := task.GetContext()
+	if raw["owner"] != "ci" || raw["build"] == nil {
+		t.Errorf("expected unknown fields to be preserved, got %v", raw)
+	}
+
+	// Omitted fields are cleared and large integers survive the round trip.
+	if err := SetContextFrom(task, deployContext{Attempts: 3, BuildID: 1<<60 + 1}); err != nil {
+		t.Fatalf("SetContextFrom() returned error: %v", err)
+	}
+	got, err = GetContextAs[deployContext](task)
+	if err != nil || got != (deployContext{Attempts: 3, BuildID: 1<<60 + 1}) {
+		t.Errorf("expected stage to be cleared and build ID to be exact, got %+v (%v)", got, err)
+	}
+	if err := td.Tasks.LoadContexts([]*Task{task}); err != nil {
+		t.Fatalf("LoadContexts() returned error: %v", err)
+	}
+	if got, _ := GetContextAs[deployContext](task); got.BuildID != 1<<60+1 {
+		t.Errorf("expected preloaded build ID to be exact, got %d", got.BuildID)
+	}
+	if raw, _ := task.GetContext(); raw["owner"] != "ci" {
+		t.Errorf("expected unknown fields to be preserved, got %v", raw)
+	}
+	fields, err := GetContextAs[map[string]interface{}](task)
+	if _, hasRev := fields[RevisionKey]; err != nil || hasRev || fields["owner"] != "ci" {
+		t.Errorf("expected context fields without the revision, got %v (%v)", fields, err)
+	}
+
+	if err := SetContextFrom(task, []int{1}); err == nil {
+		t.Error("expected error storing a non-object context")
+	}
+}
+
+func TestJSONFieldNames(t *testing.T) {
+	type Inner struct {
+		Shared string `json:"shared"`
+	}
+	type outer struct {
+		Inner
+		Nested  Inner `json:"nested"`
+		Named   *Inner
+		Skipped string `json:"-"`
+		Plain   int
+		hidden  int
+	}
+	if got := strings.Join(jsonFieldNames(reflect.TypeOf(&outer{})), " "); got != "shared nested Named Plain" {
+		t.Errorf("unexpected field names %q", got)
+	}
+	if got := jsonFieldNames(reflect.TypeOf(map[string]int{})); got != nil {
+		t.Errorf("expected no field names for maps, got %q", got)
+	}
+}
+
+func TestContextConflicts(t *testing.T) {
+	cs := newCommentServer(t)
+	cs.add("1", ContextPrefix+` {"a": 1, "_rev": 3}`)
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1", Content: "Deploy"}})
+	task := td.Tasks.Get("1")
+
+	data, rev, err := task.GetContextRevision()
+	if err != nil || rev != 3 || len(data) != 1 {
+		t.Fatalf("expected revision 3 without revision key, got %v %d (%v)", data, rev, err)
+	}
+	if err := task.CompareAndSetContext(map[string]interface{}{"a": 2}, 2); !errors.Is(err, ErrContextConflict) {
+		t.Errorf("expected conflict for stale revision, got %v", err)
+	}
+	if err := task.CompareAndSetContext(map[string]interface{}{"a": 2}, 3); err != nil {
+		t.Fatalf("CompareAndSetContext() returned error: %v", err)
+	}
+
+	// Another bot writes the same revision right after our first attempt.
+	clobbered := false
+	cs.afterUpdate = func(comment *Comment) {
+		if !clobbered {
+			clobbered = true
+			comment.Content = ContextPrefix + ` {"a": 2, "b": "other", "_rev": 5}`
+		}
+	}
+	if err := task.UpdateContext(map[string]interface{}{"c": "mine"}); err != nil {
+		t.Fatalf("UpdateContext() returned error: %v", err)
+	}
+	data, rev, _ = task.GetContextRevision()
+	if data["b"] != "other" || data["c"] != "mine" || rev != 6 {
+		t.Errorf("expected both updates at revision 6, got %v %d", data, rev)
+	}
+
+	// A write with a higher revision lands in between: ours is retried on
+	// top of it.
+	clobbered = false
+	cs.afterUpdate = func(comment *Comment) {
+		if !clobbered {
+			clobbered = true
+			comment.Content = ContextPrefix + ` {"a": 1, "d": 4, "_rev": 100}`
+		}
+	}
+	if err := task.DeleteContextField("a"); err != nil {
+		t.Fatalf("DeleteContextField() returned error: %v", err)
+	}
+	data, rev, _ = task.GetContextRevision()
+	if len(data) != 1 || data["d"] != 4.0 || rev != 101 {
+		t.Errorf("expected the field to be deleted from the newer context at revision 101, got %v %d", data, rev)
+	}
+	writes := 0
+	cs.afterUpdate = func(comment *Comment) {
+		writes++
+		var payload map[string]interface{}
+		json.Unmarshal([]byte(strings.TrimPrefix(comment.Content, ContextPrefix+" ")), &payload)
+		payload["b"] = writes
+		out, _ := json.Marshal(payload)
+		comment.Content = ContextPrefix + " " + string(out)
+	}
+	if err := task.UpdateContext(map[string]interface{}{"c": "again"}); !errors.Is(err, ErrContextConflict) {
+		t.Errorf("expected ErrContextConflict after exhausting retries, got %v", err)
+	}
+	if writes != MaxContextRetries {
+		t.Errorf("expected %d attempts, got %d", MaxContextRetries, writes)
+	}
+}
+
+func TestContextNamespaces(t *testing.T) {
+	cs := newCommentServer(t)
+	cs.add("1", ContextPrefix+` {"owner": "me"}`)
+	cs.add("1", "Just a note")
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1", Content: "Deploy"}})
+	task := td.Tasks.Get("1")
+
+	if err := task.Context("ci").Set(map[string]interface{}{"status": "green"}); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	if err := task.Context("triage").Update(map[string]interface{}{"status": "new"}); err != nil {
+		t.Fatalf("Update() returned error: %v", err)
+	}
+
+	ci, err := task.Context("ci").Get()
+	if err != nil || ci["status"] != "green" {
+		t.Errorf("expected ci context, got %v (%v)", ci, err)
+	}
+	def, _ := task.GetContext()
+	if len(def) != 1 || def["owner"] != "me" {
+		t.Errorf("expected default context to be untouched, got %v", def)
+	}
+
+	namespaces, err := task.Namespaces()
+	if err != nil || strings.Join(namespaces, ",") != ",ci,triage" {
+		t.Errorf("expected namespaces ['' ci triage], got %q (%v)", namespaces, err)
+	}
+
+	if err := task.Context("ci").Delete(); err != nil {
+		t.Fatalf("Delete() returned error: %v", err)
+	}
+	if namespaces, _ := task.Namespaces(); len(namespaces) != 2 {
+		t.Errorf("expected ci namespace to be gone, got %q", namespaces)
+	}
+
+	if _, err := task.Context("bad name").Get(); err == nil {
+		t.Error("expected error for invalid namespace")
+	}
+}
+
+func TestLoadContexts(t *testing.T) {
+	cs := newCommentServer(t)
+	cs.add("1", ContextPrefix+` {"owner": "me", "_rev": 2}`)
+	syncs := 0
+	cs.mux.HandleFunc("POST /sync", func(w http.ResponseWriter, r *http.Request) {
+		syncs++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"notes": []Note{
+				{ID: "1", ItemID: "1", Content: ContextPrefix + ` {"owner": "me", "_rev": 2}`, PostedAt: "2026-01-01T10:00:00Z"},
+				{ID: "n2", ItemID: "1", Content: "[CONTEXT:ci] {\"status\": \"green\"}", PostedAt: "2026-01-01T11:00:00Z"},
+				{ID: "n3", ItemID: "2", Content: ContextPrefix + ` {"old": true}`, IsDeleted: true},
+				{ID: "n4", ItemID: "3", Content: ContextPrefix + ` not json`},
+			},
+		})
+	})
+
+	defer func(threshold int) { ContextSyncThreshold = threshold }(ContextSyncThreshold)
+	ContextSyncThreshold = 3
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1"}, {ID: "2"}, {ID: "3"}})
+	tasks := []*Task{td.Tasks.Get("1"), td.Tasks.Get("2"), td.Tasks.Get("3")}
+
+	if err := td.Tasks.LoadContexts(tasks); err == nil || !strings.Contains(err.Error(), "task 3") {
+		t.Errorf("expected parse error for task 3, got %v", err)
+	}
+
+	data, rev, err := tasks[0].GetContextRevision()
+	if err != nil || data["owner"] != "me" || rev != 2 {
+		t.Errorf("expected preloaded context, got %v %d (%v)", data, rev, err)
+	}
+	data["owner"] = "changed"
+	if data, _ := tasks[0].GetContext(); data["owner"] != "me" {
+		t.Errorf("expected cached context to be copied, got %v", data)
+	}
+	if ci, _ := tasks[0].Context("ci").Get(); ci["status"] != "green" {
+		t.Errorf("expected preloaded ci context, got %v", ci)
+	}
+	if data, _ := tasks[1].GetContext(); len(data) != 0 {
+		t.Errorf("expected deleted note to be ignored, got %v", data)
+	}
+	if cs.gets != 0 {
+		t.Errorf("expected no comment requests for loaded contexts, got %d", cs.gets)
+	}
+
+	if err := tasks[0].UpdateContext(map[string]interface{}{"owner": "bot"}); err != nil {
+		t.Fatalf("UpdateContext() returned error: %v", err)
+	}
+	gets := cs.gets
+	if data, _ := tasks[0].GetContext(); data["owner"] != "bot" || cs.gets != gets+1 {
+		t.Errorf("expected write to invalidate the loaded context, got %v", data)
+	}
+
+	// Few tasks are loaded with a comments request each.
+	gets = cs.gets
+	if err := td.Tasks.LoadContexts(tasks[:2]); err != nil {
+		t.Fatalf("LoadContexts() returned error: %v", err)
+	}
+	if syncs != 1 || cs.gets != gets+2 {
+		t.Errorf("expected 2 comment requests and no sync, got %d and %d syncs", cs.gets-gets, syncs)
+	}
+	if data, _ := tasks[0].GetContext(); data["owner"] != "bot" || cs.gets != gets+2 {
+		t.Errorf("expected loaded context, got %v", data)
+	}
+}
+
+func TestContextValidation(t *testing.T) {
+	cs := newCommentServer(t)
+	cs.add("1", ContextPrefix+` {"owner": "me"`)
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1"}, {ID: "2"}})
+	td.RegisterContextSchema("ci", ContextSchema{
+		Required: []string{"status"},
+		Types:    map[string]string{"status": "string", "attempts": "integer"},
+	})
+	td.RegisterContextValidator("", func(data map[string]interface{}) error {
+		if _, ok := data["secret"]; ok {
+			return errors.New("secrets are not allowed")
+		}
+		return nil
+	})
+
+	broken := td.Tasks.Get("1")
+	var parseErr *ContextParseError
+	if _, err := broken.GetContext(); !errors.As(err, &parseErr) || parseErr.Raw == "" {
+		t.Errorf("expected ContextParseError, got %v", err)
+	}
+	raw, err := broken.GetContextLenient()
+	if err != nil || raw.Data != nil || !errors.As(raw.Err, &parseErr) || raw.Content != ContextPrefix+` {"owner": "me"` {
+		t.Errorf("expected lenient read to report parse error, got %+v (%v)", raw, err)
+	}
+	if err := broken.CompareAndSetContext(map[string]interface{}{"owner": "me"}, 0); err != nil {
+		t.Fatalf("expected malformed context to be replaceable at revision 0, got %v", err)
+	}
+	if data, rev, err := broken.GetContextRevision(); err != nil || data["owner"] != "me" || rev != 1 {
+		t.Errorf("expected repaired context at revision 1, got %v %d (%v)", data, rev, err)
+	}
+
+	task := td.Tasks.Get("2")
+	var validationErr *ContextValidationError
+	if err := task.Context("ci").Set(map[string]interface{}{"attempts": 1}); !errors.As(err, &validationErr) {
+		t.Errorf("expected missing status to be rejected, got %v", err)
+	}
+	if err := task.Context("ci").Set(map[string]interface{}{"status": "ok", "attempts": 1.5}); !errors.As(err, &validationErr) {
+		t.Errorf("expected non-integer attempts to be rejected, got %v", err)
+	}
+	if err := task.Context("ci").Set(map[string]interface{}{"status": "ok", "attempts": 2}); err != nil {
+		t.Errorf("expected valid context to be written, got %v", err)
+	}
+	if err := task.UpdateContext(map[string]interface{}{"secret": "hunter2"}); !errors.As(err, &validationErr) {
+		t.Errorf("expected default namespace validator to run, got %v", err)
+	}
+	if namespaces, _ := task.Namespaces(); len(namespaces) != 1 {
+		t.Errorf("expected only the ci context to be written, got %q", namespaces)
+	}
+}
+
+func TestEncryptedContext(t *testing.T) {
+	cs := newCommentServer(t)
+	cs.add("1", ContextPrefix+` {"legacy": true}`)
+
+	oldKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	newKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+
+	td := NewTodoistWithConfig(&Config{Token: "test-token", ContextKeys: []string{oldKey}})
+	td.Tasks.Update([]Task{{ID: "1"}, {ID: "2"}})
+	task := td.Tasks.Get("1")
+
+	if err := task.UpdateContext(map[string]interface{}{"customer": "ACME-42"}); err != nil {
+		t.Fatalf("UpdateContext() returned error: %v", err)
+	}
+	content := cs.comments["1"].Content
+	if !strings.HasPrefix(content, EncryptedContextPrefix+" ") || strings.Contains(content, "ACME") {
+		t.Fatalf("expected ciphertext, got %q", content)
+	}
+	data, err := task.GetContext()
+	if err != nil || data["customer"] != "ACME-42" || data["legacy"] != true {
+		t.Errorf("expected transparent decryption, got %v (%v)", data, err)
+	}
+
+	// A ciphertext copied to another task must not decrypt.
+	cs.add("2", content)
+	if _, err := td.Tasks.Get("2").GetContext(); err == nil {
+		t.Error("expected ciphertext of another task to be rejected")
+	}
+
+	keys, _ := NewStaticKeys(newKey, oldKey)
+	td.EncryptContexts(keys)
+	if err := td.Tasks.RotateContextKeys([]*Task{task}); err != nil {
+		t.Fatalf("RotateContextKeys() returned error: %v", err)
+	}
+	if cs.comments["1"].Content == content {
+		t.Error("expected context to be re-encrypted")
+	}
+
+	onlyNew, _ := NewStaticKeys(newKey)
+	td.EncryptContexts(onlyNew)
+	if data, err := task.GetContext(); err != nil || data["customer"] != "ACME-42" {
+		t.Errorf("expected rotated context to decrypt with the new key, got %v (%v)", data, err)
+	}
+
+	// Contexts that cannot be decrypted can still be overwritten.
+	copied := td.Tasks.Get("2")
+	if err := copied.SetContext(map[string]interface{}{"fresh": true}); err != nil {
+		t.Fatalf("SetContext() over undecryptable context returned error: %v", err)
+	}
+	if data, err := copied.GetContext(); err != nil || len(data) != 1 || data["fresh"] != true {
+		t.Errorf("expected undecryptable context to be replaced, got %v (%v)", data, err)
+	}
+
+	td.EncryptContexts(nil)
+	if _, err := task.GetContext(); !errors.Is(err, ErrNoContextKey) {
+		t.Errorf("expected ErrNoContextKey without keys, got %v", err)
+	}
+}
+
+func TestProjectContext(t *testing.T) {
+	newCommentServer(t)
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, 32))
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1", ProjectID: "100"}})
+	td.Projects.Update([]Project{{ID: "100", Name: "Ops"}, {ID: "1", Name: "Same ID as task"}})
+	project := td.Projects.Get("100")
+
+	if err := project.SetContext(map[string]interface{}{"team": "sre", "sla_hours": 4}); err != nil {
+		t.Fatalf("SetContext() returned error: %v", err)
+	}
+	if err := project.UpdateContext(map[string]interface{}{"repo": "git.example.com/ops"}); err != nil {
+		t.Fatalf("UpdateContext() returned error: %v", err)
+	}
+	data, err := project.GetContext()
+	if err != nil || data["team"] != "sre" || data["repo"] != "git.example.com/ops" {
+		t.Errorf("expected project context, got %v (%v)", data, err)
+	}
+	if task, _ := td.Tasks.Get("1").GetContext(); len(task) != 0 {
+		t.Errorf("expected task context to be separate, got %v", task)
+	}
+
+	keys, _ := NewStaticKeys(key)
+	td.EncryptContexts(keys)
+	other := td.Projects.Get("1")
+	if err := other.Context("ci").Set(map[string]interface{}{"url": "https://ci.example.com"}); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	if data, err := other.Context("ci").Get(); err != nil || data["url"] != "https://ci.example.com" {
+		t.Errorf("expected encrypted project context to round-trip, got %v (%v)", data, err)
+	}
+	if namespaces, _ := other.Namespaces(); len(namespaces) != 1 || namespaces[0] != "ci" {
+		t.Errorf("expected ci namespace on project, got %q", namespaces)
+	}
+
+	if err := project.DeleteContext(); err != nil {
+		t.Fatalf("DeleteContext() returned error: %v", err)
+	}
+	if data, _ := project.GetContext(); len(data) != 0 {
+		t.Errorf("expected context to be deleted, got %v", data)
+	}
+}
+
+func TestContextHistory(t *testing.T) {
+	cs := newCommentServer(t)
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1"}})
+	task := td.Tasks.Get("1")
+
+	if err := task.SetContext(map[string]interface{}{"untracked": true}); err != nil {
+		t.Fatalf("SetContext() returned error: %v", err)
+	}
+	td.EnableContextHistory("triage-bot", 2)
+	steps := []map[string]interface{}{
+		{"stage": "new"},
+		{"stage": "triaged", "owner": "ann"},
+		{"owner": "bob"},
+	}
+	for _, step := range steps {
+		if err := task.UpdateContext(step); err != nil {
+			t.Fatalf("UpdateContext() returned error: %v", err)
+		}
+	}
+	if err := task.DeleteContextField("untracked"); err != nil {
+		t.Fatalf("DeleteContextField() returned error: %v", err)
+	}
+
+	history, err := task.ContextHistory()
+	if err != nil {
+		t.Fatalf("ContextHistory() returned error: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected history to be limited to 2 changes, got %d", len(history))
+	}
+	latest, previous := history[0], history[1]
+	if latest.By != "triage-bot" || latest.Revision != 5 || strings.Join(latest.Changed, ",") != "untracked" {
+		t.Errorf("unexpected latest change: %+v", latest.ContextChange)
+	}
+	if previous.Data["owner"] != "bob" || previous.Data["untracked"] != true || previous.Old["owner"] != "ann" {
+		t.Errorf("unexpected previous revision: %+v", previous)
+	}
+
+	if namespaces, _ := task.Namespaces(); len(namespaces) != 1 || namespaces[0] != "" {
+		t.Errorf("expected history namespace to be hidden, got %q", namespaces)
+	}
+
+	if err := task.DeleteContext(); err != nil {
+		t.Fatalf("DeleteContext() returned error: %v", err)
+	}
+	history, _ = task.ContextHistory()
+	if history[0].Revision != 0 || len(history[0].Data) != 0 || history[1].Data["stage"] != "triaged" {
+		t.Errorf("expected deletion to be recorded, got %+v", history)
+	}
+
+	// Contexts that cannot be parsed are recorded as they were.
+	td.Tasks.Update([]Task{{ID: "2"}})
+	broken := td.Tasks.Get("2")
+	cs.add("2", ContextPrefix+` {"stage": `)
+	if err := broken.SetContext(map[string]interface{}{"stage": "new"}); err != nil {
+		t.Fatalf("SetContext() returned error: %v", err)
+	}
+	if err := broken.UpdateContext(map[string]interface{}{"stage": "done"}); err != nil {
+		t.Fatalf("UpdateContext() returned error: %v", err)
+	}
+	history, _ = broken.ContextHistory()
+	if len(history) != 2 || history[1].OldRaw != ContextPrefix+` {"stage": ` || history[1].Data == nil || history[0].Data["stage"] != "done" {
+		t.Errorf("expected repair of the broken context to be recorded, got %+v", history)
+	}
+	cs.add("3", ContextPrefix+` not json`)
+	td.Tasks.Update([]Task{{ID: "3"}})
+	if err := td.Tasks.Get("3").DeleteContext(); err != nil {
+		t.Fatalf("DeleteContext() returned error: %v", err)
+	}
+	history, _ = td.Tasks.Get("3").ContextHistory()
+	if len(history) != 1 || history[0].OldRaw != ContextPrefix+` not json` {
+		t.Errorf("expected deletion of the broken context to be recorded, got %+v", history)
+	}
+
+	// The history is trimmed to fit into a comment.
+	defer func(size int) { MaxContextHistorySize = size }(MaxContextHistorySize)
+	MaxContextHistorySize = 300
+	td.EnableContextHistory("triage-bot", 10)
+	for i := 0; i < 5; i++ {
+		if err := task.UpdateContext(map[string]interface{}{"note": strings.Repeat("x", 40+i)}); err != nil {
+			t.Fatalf("UpdateContext() returned error: %v", err)
+		}
+	}
+	history, _ = task.ContextHistory()
+	if len(history) == 0 || len(history) >= 5 || len(history[0].Old["note"].(string)) != 43 {
+		t.Errorf("expected history to be trimmed to its newest changes, got %d changes", len(history))
+	}
+}
+
+func TestDescriptionContextStore(t *testing.T) {
+	cs := newCommentServer(t)
+	cs.add("1", ContextPrefix+` {"source": "email"}`)
+	cs.add("1", "[CONTEXT:ci] {\"status\": \"green\"}")
+
+	patched := 0
+	cs.mux.HandleFunc("POST /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &payload)
+		patched++
+		json.NewEncoder(w).Encode(Task{ID: r.PathValue("id"), Content: "Reply", Description: payload["description"].(string)})
+	})
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1", Content: "Reply", Description: "Customer asked about invoices.", NoteCount: 2}, {ID: "2"}})
+	task := td.Tasks.Get("1")
+
+	store := DescriptionContextStore{}
+	if err := td.Tasks.MigrateContexts(td.Tasks.All(), CommentContextStore{}, store); err != nil {
+		t.Fatalf("MigrateContexts() returned error: %v", err)
+	}
+	if cs.gets != 1 || patched != 2 {
+		t.Errorf("expected task without comments to be skipped, got %d comment and %d task requests", cs.gets, patched)
+	}
+	if len(cs.comments) != 0 {
+		t.Errorf("expected context comments to be removed, got %v", cs.comments)
+	}
+	expected := "Customer asked about invoices.\n\n```context\n{\"source\": \"email\"}\n```\n\n```context:ci\n{\"status\": \"green\"}\n```"
+	if task.Description != expected {
+		t.Errorf("unexpected description:\n%s", task.Description)
+	}
+
+	td.UseContextStore(store)
+	gets := cs.gets
+	if data, err := task.Context("ci").Get(); err != nil || data["status"] != "green" {
+		t.Errorf("expected ci context from description, got %v (%v)", data, err)
+	}
+	if err := task.UpdateContext(map[string]interface{}{"urgency": "high"}); err != nil {
+		t.Fatalf("UpdateContext() returned error: %v", err)
+	}
+	if data, _ := task.GetContext(); data["source"] != "email" || data["urgency"] != "high" {
+		t.Errorf("expected updated context, got %v", data)
+	}
+	if cs.gets != gets {
+		t.Errorf("expected no comment requests, got %d", cs.gets-gets)
+	}
+	if !strings.HasPrefix(task.Description, "Customer asked about invoices.\n\n```context:ci\n") {
+		t.Errorf("expected rewritten block at the end, got:\n%s", task.Description)
+	}
+	if results := td.Tasks.Search("urgency"); len(results) != 0 {
+		t.Errorf("expected context blocks not to be searchable, got %v", results)
+	}
+
+	// Setting the description keeps the context blocks.
+	blocks := strings.TrimPrefix(task.Description, "Customer asked about invoices.")
+	if err := task.Patch().Description("Customer asked about refunds.").Apply(); err != nil {
+		t.Fatalf("Apply() returned error: %v", err)
+	}
+	if err := task.Update("Description", "Customer asked about refunds.\n"+blocks); err != nil {
+		t.Fatalf("Update() returned error: %v", err)
+	}
+	if task.Description != "Customer asked about refunds."+blocks {
+		t.Errorf("expected context blocks to be kept, got:\n%s", task.Description)
+	}
+
+	// Descriptions changed by a sync are not shadowed by cached comments.
+	td.UseStore(NewFileStore(filepath.Join(t.TempDir(), "state.json")))
+	td.CacheContextReads(true)
+	if data, _ := task.GetContext(); data["urgency"] != "high" {
+		t.Errorf("expected context from description, got %v", data)
+	}
+	td.Tasks.Update([]Task{{ID: "1", Content: "Reply", Description: "Synced.\n\n```context\n{\"urgency\": \"low\"}\n```"}})
+	task = td.Tasks.Get("1")
+	if data, _ := task.GetContext(); data["urgency"] != "low" {
+		t.Errorf("expected context from the synced description, got %v", data)
+	}
+
+	if err := task.DeleteContext(); err != nil {
+		t.Fatalf("DeleteContext() returned error: %v", err)
+	}
+	if err := task.Context("ci").Delete(); err != nil {
+		t.Fatalf("Delete() returned error: %v", err)
+	}
+	if task.Description != "Synced." || patched == 0 {
+		t.Errorf("expected description without context, got %q", task.Description)
+	}
+}
+
+func TestContextQueries(t *testing.T) {
+	defer func(threshold int) { ContextSyncThreshold = threshold }(ContextSyncThreshold)
+	ContextSyncThreshold = 1
+
+	newCommentServer(t).mux.HandleFunc("POST /sync", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"notes": []Note{
+				{ID: "n1", ItemID: "1", Content: ContextPrefix + ` {"source": "email", "urgency": "high", "retries": 5}`},
+				{ID: "n2", ItemID: "2", Content: ContextPrefix + ` {"source": "email", "urgency": "low", "retries": 1}`},
+				{ID: "n3", ItemID: "3", Content: ContextPrefix + ` {"source": "chat", "build": {"ok": false}, "größe": 1}`},
+				{ID: "n4", ItemID: "3", Content: "[CONTEXT:ci] {\"status\": \"red\"}"},
+			},
+		})
+	})
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}})
+	if err := td.Tasks.LoadContexts(td.Tasks.All()); err != nil {
+		t.Fatalf("LoadContexts() returned error: %v", err)
+	}
+
+	if got := taskIDs(td.Tasks.WhereContext("source", "email")); got != "1 2" {
+		t.Errorf("expected tasks 1 and 2 from email, got %q", got)
+	}
+	if got := taskIDs(td.Tasks.WhereContext("retries", 5)); got != "1" {
+		t.Errorf("expected int values to match decoded numbers, got %q", got)
+	}
+	if got := taskIDs(td.Tasks.WhereContext("build.ok", false)); got != "3" {
+		t.Errorf("expected nested lookup to match task 3, got %q", got)
+	}
+
+	queries := map[string]string{
+		`context.urgency == "high" && context.retries > 3`:        "1",
+		`context.source == "email" && !(context.retries >= 5)`:    "2",
+		`contexts.ci.status == "red" || context.urgency == "low"`: "2 3",
+		`context.build && context.build.ok != true`:               "3",
+		`context.missing == null && context.source != "chat"`:     "1 2 4",
+		`context.retries < "3"`:                                   "",
+		`context.source=="chat"||(context.retries<=1&&true)`:      "2 3",
+		`context.größe == 1`:                                      "3",
+	}
+	for expr, expected := range queries {
+		tasks, err := td.Tasks.QueryContext(expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", expr, err)
+			continue
+		}
+		if got := taskIDs(tasks); got != expected {
+			t.Errorf("%s: expected %q, got %q", expr, expected, got)
+		}
+	}
+
+	for _, expr := range []string{`context.a = 1`, `urgency == "high"`, `(context.a`, `context.a == "x`, `context.a ==`, `contexts._history.a`, "context.a == \xff"} {
+		if _, err := ParseContextQuery(expr); err == nil {
+			t.Errorf("%s: expected parse error", expr)
+		}
+	}
+}
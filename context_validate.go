@@ -0,0 +1,182 @@
+package godoist
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// ContextValidator checks context data before it is written.
+type ContextValidator func(contextData map[string]interface{}) error
+
+// ContextValidationError is returned when context data is rejected by the
+// validator registered for its namespace.
+type ContextValidationError struct {
+	Namespace string
+	Err       error
+}
+
+func (e *ContextValidationError) Error() string {
+	return fmt.Sprintf("invalid context for namespace %q: %v", e.Namespace, e.Err)
+}
+
+func (e *ContextValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ContextParseError is returned when a context comment does not hold a
+// JSON object. Raw is the content of the comment.
+type ContextParseError struct {
+	Namespace string
+	Raw       string
+	Err       error
+}
+
+func (e *ContextParseError) Error() string {
+	return fmt.Sprintf("failed to parse context: %v", e.Err)
+}
+
+func (e *ContextParseError) Unwrap() error {
+	return e.Err
+}
+
+// ContextSchema is a small subset of JSON Schema for context data.
+type ContextSchema struct {
+	// Required lists the fields that must be present.
+	Required []string
+	// Types maps fields to their JSON type: "string", "number", "integer",
+	// "boolean", "object", "array" or "null".
+	Types map[string]string
+	// Strict rejects fields not listed in Types.
+	Strict bool
+}
+
+// Validate checks contextData against the schema.
+func (s ContextSchema) Validate(contextData map[string]interface{}) error {
+	for _, key := range s.Required {
+		if _, ok := contextData[key]; !ok {
+			return fmt.Errorf("missing required field %q", key)
+		}
+	}
+
+	keys := make([]string, 0, len(contextData))
+	for key := range contextData {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		expected, ok := s.Types[key]
+		if !ok {
+			if s.Strict {
+				return fmt.Errorf("unexpected field %q", key)
+			}
+			continue
+		}
+		if actual := jsonType(contextData[key]); actual != expected && (expected != "number" || actual != "integer") {
+			return fmt.Errorf("field %q must be of type %s, got %s", key, expected, actual)
+		}
+	}
+	return nil
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value.
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == float64(int64(value)) {
+			return "integer"
+		}
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// RegisterContextValidator makes writes to the context namespace fail
+// unless validate accepts the data. The empty namespace is the default
+// context. A nil validator removes the registration.
+func (t *Todoist) RegisterContextValidator(namespace string, validate ContextValidator) {
+	if validate == nil {
+		delete(t.Tasks.validators, namespace)
+		return
+	}
+	t.Tasks.validators[namespace] = validate
+}
+
+// RegisterContextSchema validates writes to the context namespace against
+// schema.
+func (t *Todoist) RegisterContextSchema(namespace string, schema ContextSchema) {
+	t.RegisterContextValidator(namespace, schema.Validate)
+}
+
+// validateContext runs the validator registered for namespace, if any.
+// Validators see the data as it is stored, i.e. decoded from JSON.
+func (t *TaskManager) validateContext(namespace string, contextData map[string]interface{}) error {
+	validate, ok := t.validators[namespace]
+	if !ok {
+		return nil
+	}
+
+	// Validate the data as readers will decode it.
+	contextJSON, err := json.Marshal(contextData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal context: %w", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(contextJSON, &decoded); err != nil {
+		return fmt.Errorf("failed to marshal context: %w", err)
+	}
+	if err := validate(decoded); err != nil {
+		return &ContextValidationError{Namespace: namespace, Err: err}
+	}
+	return nil
+}
+
+// RawContext is the result of a lenient context read.
+type RawContext struct {
+	// Content is the content of the context comment, empty if there is none.
+	Content string
+	// Data is the parsed context data, nil if it could not be parsed.
+	Data     map[string]interface{}
+	Revision int
+	// Err is a *ContextParseError if the comment does not hold valid JSON,
+	// or a *ContextValidationError if the data is rejected by the validator
+	// of the namespace.
+	Err error
+}
+
+// GetLenient reads the context without failing on malformed content. Only
+// API errors are returned as error; problems with the content itself are
+// reported in RawContext.Err.
+func (c *Context) GetLenient() (*RawContext, error) {
+	comment, err := c.getComment()
+	if err != nil {
+		return nil, err
+	}
+
+	raw := &RawContext{}
+	if comment != nil {
+		raw.Content = comment.Content
+	}
+	raw.Data, raw.Revision, raw.Err = c.manager.parseContext(comment)
+	if raw.Err == nil {
+		raw.Err = c.manager.validateContext(c.namespace, raw.Data)
+	}
+	return raw, nil
+}
+
+// GetContextLenient reads the default context of a task without failing
+// on malformed content, see Context.GetLenient.
+func (t *Task) GetContextLenient() (*RawContext, error) {
+	return t.Context("").GetLenient()
+}
@@ -0,0 +1,239 @@
+package godoist
+
+import "time"
+
+// CreateTaskOptions holds every field that can be set when creating a task.
+// Zero values are not sent, leaving the server defaults in place.
+type CreateTaskOptions struct {
+	Content     string
+	Description string
+	ProjectID   string
+	SectionID   string
+	ParentID    string
+	Order       int
+	Labels      []string
+	Priority    PRIORITY_LEVEL
+	AssigneeID  string
+	// DueString sets the due date from natural language, e.g. "every monday".
+	// It takes precedence over DueDate and DueDatetime.
+	DueString string
+	// DueDate sets an all-day due date, only the date part is used.
+	DueDate time.Time
+	// DueDatetime sets a due date with a time of day.
+	DueDatetime time.Time
+	DueLang     string
+	Duration    *Duration
+	// DeadlineDate sets the deadline, only the date part is used.
+	DeadlineDate time.Time
+}
+
+// fields converts the options to the request body of the create endpoint.
+func (o CreateTaskOptions) fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"content": o.Content,
+	}
+	if o.Description != "" {
+		fields["description"] = o.Description
+	}
+	if o.ProjectID != "" {
+		fields["project_id"] = o.ProjectID
+	}
+	if o.SectionID != "" {
+		fields["section_id"] = o.SectionID
+	}
+	if o.ParentID != "" {
+		fields["parent_id"] = o.ParentID
+	}
+	if o.Order != 0 {
+		fields["order"] = o.Order
+	}
+	if len(o.Labels) > 0 {
+		fields["labels"] = o.Labels
+	}
+	if o.Priority != 0 {
+		fields["priority"] = o.Priority
+	}
+	if o.AssigneeID != "" {
+		fields["assignee_id"] = o.AssigneeID
+	}
+	switch {
+	case o.DueString != "":
+		fields["due_string"] = o.DueString
+	case !o.DueDatetime.IsZero():
+		fields["due_datetime"] = o.DueDatetime.UTC().Format(time.RFC3339)
+	case !o.DueDate.IsZero():
+		fields["due_date"] = o.DueDate.Format(time.DateOnly)
+	}
+	if o.DueLang != "" {
+		fields["due_lang"] = o.DueLang
+	}
+	if o.Duration != nil {
+		fields["duration"] = o.Duration.Amount
+		fields["duration_unit"] = o.Duration.Unit
+	}
+	if !o.DeadlineDate.IsZero() {
+		fields["deadline_date"] = o.DeadlineDate.Format(time.DateOnly)
+	}
+	return fields
+}
+
+// localTask builds the task as it is kept locally until the server has
+// seen it, used for creations journaled while offline.
+func (o CreateTaskOptions) localTask(id string) Task {
+	task := Task{
+		ID:          id,
+		Content:     o.Content,
+		Description: o.Description,
+		ProjectID:   o.ProjectID,
+		SectionID:   o.SectionID,
+		ParentID:    o.ParentID,
+		ChildOrder:  o.Order,
+		Labels:      o.Labels,
+		Priority:    o.Priority,
+		Duration:    o.Duration,
+	}
+	if task.Priority == 0 {
+		task.Priority = VERY_LOW
+	}
+	switch {
+	case o.DueString != "":
+		task.Due = &Due{String: o.DueString, Lang: o.DueLang}
+	case !o.DueDatetime.IsZero():
+		task.Due = &Due{Date: o.DueDatetime.UTC().Format(time.RFC3339), Lang: o.DueLang, ParsedDate: o.DueDatetime}
+	case !o.DueDate.IsZero():
+		date := o.DueDate.Format(time.DateOnly)
+		parsed, _ := parseDueDate(date, "")
+		task.Due = &Due{Date: date, Lang: o.DueLang, ParsedDate: parsed}
+	}
+	if !o.DeadlineDate.IsZero() {
+		date := o.DeadlineDate.Format(time.DateOnly)
+		parsed, _ := parseDueDate(date, "")
+		task.Deadline = &Deadline{Date: date, ParsedDate: parsed}
+	}
+	return task
+}
+
+// optionsFromTask converts a task to creation options, as used by AddTask.
+func optionsFromTask(task Task) CreateTaskOptions {
+	opts := CreateTaskOptions{
+		Content:     task.Content,
+		Description: task.Description,
+		ProjectID:   task.ProjectID,
+		SectionID:   task.SectionID,
+		ParentID:    task.ParentID,
+		Order:       task.ChildOrder,
+		Labels:      task.Labels,
+		Priority:    task.Priority,
+		Duration:    task.Duration,
+	}
+	if task.Due != nil {
+		opts.DueLang = task.Due.Lang
+		due := *task.Due
+		var err error
+		due.ParsedDate, err = parsedOr(due.ParsedDate, due.Date, due.Timezone)
+		switch {
+		case due.String != "":
+			opts.DueString = due.String
+		case err != nil:
+			// Let the server make sense of dates we cannot parse.
+			opts.DueString = due.Date
+		case due.HasTime():
+			opts.DueDatetime = due.Local()
+		default:
+			opts.DueDate = due.ParsedDate
+		}
+	}
+	if task.Deadline != nil {
+		opts.DeadlineDate, _ = parsedOr(task.Deadline.ParsedDate, task.Deadline.Date, "")
+	}
+	return opts
+}
+
+// parsedOr returns parsed, or parses date if parsed is zero, as for tasks
+// built by hand rather than decoded from the API.
+func parsedOr(parsed time.Time, date, timezone string) (time.Time, error) {
+	if !parsed.IsZero() || date == "" {
+		return parsed, nil
+	}
+	return parseDueDate(date, timezone)
+}
+
+// CreateWith creates a task and returns it as stored by the server. While
+// offline the task is kept under a temporary ID until the journal is
+// replayed.
+func (t *TaskManager) CreateWith(opts CreateTaskOptions) (*Task, error) {
+	fields := opts.fields()
+	cmd := NewCommand("item_add", syncArgs(fields))
+	cmd.TempID = newUUID()
+
+	var created *Task
+	err := t.submit(nil, func() error {
+		var err error
+		created, err = t.api.CreateTask(fields)
+		return err
+	}, cmd)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		local := opts.localTask(cmd.TempID)
+		created = &local
+	}
+
+	created.manager = t
+	t.put(created)
+	return created, nil
+}
+
+// syncArgs converts a request body of the REST task endpoints to the
+// arguments of the equivalent Sync API command.
+func syncArgs(fields map[string]interface{}) map[string]interface{} {
+	args := make(map[string]interface{}, len(fields))
+	due := make(map[string]interface{})
+	duration := make(map[string]interface{})
+	deadline := make(map[string]interface{})
+	clearDeadline := false
+
+	for key, value := range fields {
+		switch key {
+		case "due_string":
+			due["string"] = value
+		case "due_date", "due_datetime":
+			due["date"] = value
+		case "due_lang":
+			due["lang"] = value
+		case "duration":
+			duration["amount"] = value
+		case "duration_unit":
+			duration["unit"] = value
+		case "deadline_date":
+			if value == nil {
+				clearDeadline = true
+			}
+			deadline["date"] = value
+		case "deadline_lang":
+			deadline["lang"] = value
+		case "assignee_id":
+			args["responsible_uid"] = value
+		case "order":
+			args["child_order"] = value
+		default:
+			args[key] = value
+		}
+	}
+
+	if len(due) > 0 {
+		args["due"] = due
+	}
+	if amount, ok := duration["amount"]; ok && amount == nil {
+		args["duration"] = nil
+	} else if len(duration) > 0 {
+		args["duration"] = duration
+	}
+	if clearDeadline {
+		args["deadline"] = nil
+	} else if len(deadline) > 0 {
+		args["deadline"] = deadline
+	}
+	return args
+}
@@ -0,0 +1,123 @@
+package godoist
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateWith(t *testing.T) {
+	var receivedBody map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tasks", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &receivedBody)
+		json.NewEncoder(w).Encode(Task{ID: "42", Content: receivedBody["content"].(string), Priority: VERY_LOW})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	task, err := td.Tasks.CreateWith(CreateTaskOptions{
+		Content:      "Water plants",
+		Priority:     VERY_LOW,
+		DueString:    "every monday",
+		DueLang:      "en",
+		AssigneeID:   "user-7",
+		Order:        3,
+		Duration:     &Duration{Amount: 15, Unit: "minute"},
+		DeadlineDate: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("CreateWith() returned error: %v", err)
+	}
+	if task.ID != "42" || td.Tasks.Get("42") != task {
+		t.Fatalf("expected created task to be returned and cached, got %+v", task)
+	}
+
+	expected := map[string]interface{}{
+		"content":       "Water plants",
+		"priority":      float64(VERY_LOW),
+		"due_string":    "every monday",
+		"due_lang":      "en",
+		"assignee_id":   "user-7",
+		"order":         float64(3),
+		"duration":      float64(15),
+		"duration_unit": "minute",
+		"deadline_date": "2026-03-09",
+	}
+	if len(receivedBody) != len(expected) {
+		t.Errorf("expected %d fields, got %v", len(expected), receivedBody)
+	}
+	for key, value := range expected {
+		if receivedBody[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, receivedBody[key])
+		}
+	}
+}
+
+func TestSyncArgs(t *testing.T) {
+	args := syncArgs(map[string]interface{}{
+		"content":     "Water plants",
+		"due_string":  "tomorrow",
+		"assignee_id": "user-7",
+	})
+	due, ok := args["due"].(map[string]interface{})
+	if !ok || due["string"] != "tomorrow" {
+		t.Errorf("expected due object, got %v", args["due"])
+	}
+	if args["responsible_uid"] != "user-7" || args["content"] != "Water plants" {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+func TestAddTaskDueFromDate(t *testing.T) {
+	var receivedBody map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tasks", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receivedBody = nil
+		json.Unmarshal(body, &receivedBody)
+		json.NewEncoder(w).Encode(Task{ID: "42", Content: receivedBody["content"].(string)})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	err := td.Tasks.AddTask(Task{
+		Content:  "File taxes",
+		Due:      &Due{Date: "2026-03-01"},
+		Deadline: &Deadline{Date: "2026-03-15"},
+	})
+	if err != nil {
+		t.Fatalf("AddTask() returned error: %v", err)
+	}
+	if receivedBody["due_date"] != "2026-03-01" {
+		t.Errorf("expected due_date 2026-03-01, got %v", receivedBody["due_date"])
+	}
+	if receivedBody["deadline_date"] != "2026-03-15" {
+		t.Errorf("expected deadline_date 2026-03-15, got %v", receivedBody["deadline_date"])
+	}
+
+	if err := td.Tasks.AddTask(Task{Content: "Call mom", Due: &Due{Date: "next week"}}); err != nil {
+		t.Fatalf("AddTask() returned error: %v", err)
+	}
+	if receivedBody["due_string"] != "next week" {
+		t.Errorf("expected unparsable date to be sent as due_string, got %v", receivedBody)
+	}
+}
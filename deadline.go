@@ -0,0 +1,104 @@
+package godoist
+
+import (
+	"sort"
+	"time"
+)
+
+// Deadline sets the deadline, only the date part of date is used.
+func (p *TaskPatch) Deadline(date time.Time) *TaskPatch {
+	value := date.Format(time.DateOnly)
+	return p.set("deadline_date", value, func(t *Task) {
+		parsed, _ := parseDueDate(value, "")
+		t.Deadline = &Deadline{Date: value, ParsedDate: parsed}
+	})
+}
+
+// ClearDeadline removes the deadline.
+func (p *TaskPatch) ClearDeadline() *TaskPatch {
+	return p.set("deadline_date", nil, func(t *Task) { t.Deadline = nil })
+}
+
+// deadline sets the deadline from a Deadline as read from the API.
+func (p *TaskPatch) deadline(deadline *Deadline) *TaskPatch {
+	if deadline == nil {
+		return p.ClearDeadline()
+	}
+	date := deadline.ParsedDate
+	if date.IsZero() {
+		date, _ = parseDueDate(deadline.Date, "")
+	}
+	p.Deadline(date)
+	if deadline.Lang != "" {
+		p.set("deadline_lang", deadline.Lang, func(t *Task) { t.Deadline.Lang = deadline.Lang })
+	}
+	return p
+}
+
+// SetDeadline sets the deadline of the task, only the date part is used.
+func (t *Task) SetDeadline(date time.Time) error {
+	return t.Patch().Deadline(date).Apply()
+}
+
+// ClearDeadline removes the deadline of the task.
+func (t *Task) ClearDeadline() error {
+	return t.Patch().ClearDeadline().Apply()
+}
+
+// DeadlineBeforeDue reports whether the task is due after its deadline.
+func (t *Task) DeadlineBeforeDue() bool {
+	if t.Deadline == nil || t.Due == nil || t.Due.ParsedDate.IsZero() {
+		return false
+	}
+	due := t.Due.Local()
+	dueDay := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.Local)
+	return dueDay.After(t.Deadline.Local())
+}
+
+// startOfDay returns midnight of the day of t in the local timezone, the
+// timezone deadlines are compared in.
+func startOfDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// filterDeadlines returns the tasks with a deadline accepted by keep,
+// ordered by deadline.
+func (t *TaskManager) filterDeadlines(keep func(task *Task) bool) []*Task {
+	tasks := make([]*Task, 0)
+	for _, task := range t.tasks {
+		if task.Deadline != nil && keep(task) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Deadline.ParsedDate.Before(tasks[j].Deadline.ParsedDate)
+	})
+	return tasks
+}
+
+// DeadlineWithin returns the tasks whose deadline is between today and d
+// from now, ordered by deadline.
+func (t *TaskManager) DeadlineWithin(d time.Duration) []*Task {
+	now := time.Now()
+	today, limit := startOfDay(now), now.Add(d)
+	return t.filterDeadlines(func(task *Task) bool {
+		deadline := task.Deadline.Local()
+		return !deadline.Before(today) && !deadline.After(limit)
+	})
+}
+
+// OverdueDeadlines returns the tasks whose deadline day ended before now,
+// ordered by deadline.
+func (t *TaskManager) OverdueDeadlines(now time.Time) []*Task {
+	today := startOfDay(now)
+	return t.filterDeadlines(func(task *Task) bool {
+		return task.Deadline.Local().Before(today)
+	})
+}
+
+// DeadlineBeforeDue returns the tasks that are due after their deadline,
+// ordered by deadline.
+func (t *TaskManager) DeadlineBeforeDue() []*Task {
+	return t.filterDeadlines((*Task).DeadlineBeforeDue)
+}
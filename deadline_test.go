@@ -0,0 +1,53 @@
+package godoist
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDeadlineQueries(t *testing.T) {
+	now := time.Now()
+	day := func(offset int) string {
+		return now.AddDate(0, 0, offset).Format(time.DateOnly)
+	}
+
+	var tasks []Task
+	data := `[
+		{"id": "overdue", "deadline": {"date": "` + day(-2) + `"}},
+		{"id": "today", "deadline": {"date": "` + day(0) + `"}},
+		{"id": "soon", "deadline": {"date": "` + day(3) + `"}, "due": {"date": "` + day(5) + `"}},
+		{"id": "later", "deadline": {"date": "` + day(30) + `"}, "due": {"date": "` + day(1) + `"}},
+		{"id": "none"}
+	]`
+	if err := json.Unmarshal([]byte(data), &tasks); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update(tasks)
+
+	ids := func(tasks []*Task) []string {
+		result := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			result = append(result, task.ID)
+		}
+		return result
+	}
+	check := func(name string, got []*Task, expected ...string) {
+		t.Helper()
+		if gotIDs := ids(got); len(gotIDs) != len(expected) {
+			t.Errorf("%s: expected %v, got %v", name, expected, gotIDs)
+		} else {
+			for i := range expected {
+				if gotIDs[i] != expected[i] {
+					t.Errorf("%s: expected %v, got %v", name, expected, gotIDs)
+				}
+			}
+		}
+	}
+
+	check("DeadlineWithin", td.Tasks.DeadlineWithin(7*24*time.Hour), "today", "soon")
+	check("OverdueDeadlines", td.Tasks.OverdueDeadlines(now), "overdue")
+	check("DeadlineBeforeDue", td.Tasks.DeadlineBeforeDue(), "soon")
+}
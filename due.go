@@ -0,0 +1,180 @@
+package godoist
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// floatingLayout is the format of due datetimes without a timezone. They
+// are "floating": 9am means 9am wherever the user currently is.
+const floatingLayout = "2006-01-02T15:04:05"
+
+// parseDueDate parses the date field of a due date. All-day and floating
+// dates are returned as UTC with their wall clock time, see Due.Local for
+// their local interpretation. Fixed dates (UTC with a "Z" suffix) are
+// converted to their timezone if it is known.
+func parseDueDate(date, timezone string) (time.Time, error) {
+	switch {
+	case len(date) == len(time.DateOnly):
+		return time.Parse(time.DateOnly, date)
+	case strings.HasSuffix(date, "Z") || strings.LastIndexAny(date, "+-") > len(time.DateOnly):
+		parsed, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if timezone != "" {
+			if loc, err := time.LoadLocation(timezone); err == nil {
+				parsed = parsed.In(loc)
+			}
+		}
+		return parsed, nil
+	default:
+		return time.Parse(floatingLayout, date)
+	}
+}
+
+// wallClock returns the date and time of day of t in loc.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// Local returns the due date in the local timezone. All-day and floating
+// dates keep their wall clock time, fixed dates are converted.
+func (d *Due) Local() time.Time {
+	switch {
+	case d.ParsedDate.IsZero() || d.Date == "":
+		return d.ParsedDate
+	case d.HasTime() && !d.IsFloating():
+		return d.ParsedDate.In(time.Local)
+	default:
+		return wallClock(d.ParsedDate, time.Local)
+	}
+}
+
+// Local returns midnight of the deadline in the local timezone.
+func (d *Deadline) Local() time.Time {
+	if d.ParsedDate.IsZero() {
+		return d.ParsedDate
+	}
+	return wallClock(d.ParsedDate, time.Local)
+}
+
+// HasTime reports whether the due date has a time of day.
+func (d *Due) HasTime() bool {
+	return len(d.Date) > len(time.DateOnly)
+}
+
+// IsFloating reports whether the due date has a time of day that is not
+// bound to a timezone.
+func (d *Due) IsFloating() bool {
+	return d.HasTime() && d.Timezone == "" && !strings.HasSuffix(d.Date, "Z")
+}
+
+// DueLang sets the language used to parse DueString.
+func (p *TaskPatch) DueLang(lang string) *TaskPatch {
+	return p.set("due_lang", lang, func(t *Task) {
+		if t.Due != nil {
+			t.Due.Lang = lang
+		}
+	})
+}
+
+// DueDate sets an all-day due date, only the date part of date is used.
+func (p *TaskPatch) DueDate(date time.Time) *TaskPatch {
+	value := date.Format(time.DateOnly)
+	return p.set("due_date", value, func(t *Task) {
+		parsed, _ := parseDueDate(value, "")
+		t.Due = &Due{Date: value, ParsedDate: parsed}
+	})
+}
+
+// DueDatetime sets a due date with a time of day in the user's timezone.
+// Use Task.SetDueDateTime to choose the timezone.
+func (p *TaskPatch) DueDatetime(at time.Time) *TaskPatch {
+	value := at.UTC().Format(time.RFC3339)
+	return p.set("due_datetime", value, func(t *Task) {
+		t.Due = &Due{Date: value, ParsedDate: at}
+	})
+}
+
+// ClearDue removes the due date.
+func (p *TaskPatch) ClearDue() *TaskPatch {
+	return p.set("due_string", "no date", func(t *Task) { t.Due = nil })
+}
+
+// Due sets the due date from a Due as read from the API.
+func (p *TaskPatch) Due(due *Due) *TaskPatch {
+	if due == nil {
+		return p.ClearDue()
+	}
+
+	parsed := *due
+	if parsed.ParsedDate.IsZero() && parsed.Date != "" {
+		parsed.ParsedDate, _ = parseDueDate(due.Date, due.Timezone)
+	}
+	at := parsed.Local()
+	switch {
+	case due.String != "":
+		p.DueString(due.String)
+	case due.HasTime():
+		p.DueDatetime(at)
+	default:
+		p.DueDate(at)
+	}
+	if due.Lang != "" {
+		p.DueLang(due.Lang)
+	}
+	return p
+}
+
+// SetDueString sets the due date from natural language such as
+// "every monday 9am". An empty lang uses the user's language.
+func (t *Task) SetDueString(due, lang string) error {
+	patch := t.Patch().DueString(due)
+	if lang != "" {
+		patch.DueLang(lang)
+	}
+	return patch.Apply()
+}
+
+// SetDueDate sets an all-day due date, only the date part of date is used.
+func (t *Task) SetDueDate(date time.Time) error {
+	return t.Patch().DueDate(date).Apply()
+}
+
+// SetDueDateTime sets a due date with a time of day. With an empty
+// timezone the due date is floating: the wall clock time of at is kept
+// wherever the user is. Otherwise it is fixed to the given IANA timezone.
+func (t *Task) SetDueDateTime(at time.Time, timezone string) error {
+	due := Due{Timezone: timezone}
+	args := map[string]interface{}{}
+	if timezone == "" {
+		due.Date = at.Format(floatingLayout)
+		due.ParsedDate, _ = parseDueDate(due.Date, "")
+		args["timezone"] = nil
+	} else {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		due.Date = at.UTC().Format(time.RFC3339)
+		due.ParsedDate = at.In(loc)
+		args["timezone"] = timezone
+	}
+	args["date"] = due.Date
+
+	cmd := NewCommand("item_update", map[string]interface{}{"id": t.ID, "due": args})
+	if err := t.manager.execute(t, cmd); err != nil {
+		return err
+	}
+	t.Due = &due
+	t.manager.Reindex(t)
+	t.manager.refresh(t)
+	return nil
+}
+
+// ClearDue removes the due date of the task.
+func (t *Task) ClearDue() error {
+	return t.Patch().ClearDue().Apply()
+}
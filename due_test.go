@@ -0,0 +1,102 @@
+package godoist
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDueParsedDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		json     string
+		expected time.Time
+		floating bool
+		local    time.Time
+	}{
+		{"all day", `{"date":"2026-03-02"}`, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), false, time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)},
+		{"floating", `{"date":"2026-03-02T09:30:00"}`, time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC), true, time.Date(2026, 3, 2, 9, 30, 0, 0, time.Local)},
+		{"fixed utc", `{"date":"2026-03-02T08:30:00Z","timezone":"Europe/Berlin"}`, time.Date(2026, 3, 2, 9, 30, 0, 0, berlin), false, time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)},
+		{"fixed without timezone", `{"date":"2026-03-02T08:30:00Z"}`, time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC), false, time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var due Due
+			if err := json.Unmarshal([]byte(tt.json), &due); err != nil {
+				t.Fatalf("Unmarshal() returned error: %v", err)
+			}
+			if !due.ParsedDate.Equal(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, due.ParsedDate)
+			}
+			if due.IsFloating() != tt.floating {
+				t.Errorf("expected IsFloating() %v", tt.floating)
+			}
+			if local := due.Local(); !local.Equal(tt.local) || local.Location() != time.Local {
+				t.Errorf("expected Local() %v, got %v", tt.local, local)
+			}
+		})
+	}
+}
+
+func TestSetDueDateTime(t *testing.T) {
+	var received []Command
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sync", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload struct {
+			Commands []Command `json:"commands"`
+		}
+		json.Unmarshal(body, &payload)
+		received = payload.Commands
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sync_status": map[string]string{payload.Commands[0].UUID: "ok"},
+		})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1", Content: "Standup"}})
+	task := td.Tasks.Get("1")
+
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	if err := task.SetDueDateTime(at, "America/New_York"); err != nil {
+		t.Fatalf("SetDueDateTime() returned error: %v", err)
+	}
+	due := received[0].Args["due"].(map[string]interface{})
+	if due["date"] != "2026-03-02T09:00:00Z" || due["timezone"] != "America/New_York" {
+		t.Errorf("unexpected fixed due: %v", due)
+	}
+	if !task.Due.ParsedDate.Equal(at) || task.Due.IsFloating() {
+		t.Errorf("unexpected local due: %+v", task.Due)
+	}
+
+	if err := task.SetDueDateTime(at, ""); err != nil {
+		t.Fatalf("SetDueDateTime() returned error: %v", err)
+	}
+	due = received[0].Args["due"].(map[string]interface{})
+	if due["date"] != "2026-03-02T09:00:00" || due["timezone"] != nil {
+		t.Errorf("unexpected floating due: %v", due)
+	}
+	if !task.Due.IsFloating() {
+		t.Error("expected floating due date")
+	}
+
+	if err := task.SetDueDateTime(at, "Not/AZone"); err == nil {
+		t.Error("expected error for invalid timezone")
+	}
+}
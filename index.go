@@ -0,0 +1,156 @@
+package godoist
+
+import "time"
+
+// taskIndex maps a key, such as a project ID, to the tasks having it.
+type taskIndex map[string]map[string]*Task
+
+func (ix taskIndex) add(key string, task *Task) {
+	if ix[key] == nil {
+		ix[key] = make(map[string]*Task)
+	}
+	ix[key][task.ID] = task
+}
+
+func (ix taskIndex) remove(key, id string) {
+	delete(ix[key], id)
+	if len(ix[key]) == 0 {
+		delete(ix, key)
+	}
+}
+
+// get returns the tasks with key ordered by child order.
+func (ix taskIndex) get(key string) []*Task {
+	tasks := make([]*Task, 0, len(ix[key]))
+	for _, task := range ix[key] {
+		tasks = append(tasks, task)
+	}
+	sortSiblings(tasks)
+	return tasks
+}
+
+// indexKeys are the keys a task was indexed under, needed to remove it
+// again once its fields have changed.
+type indexKeys struct {
+	content string
+	project string
+	section string
+	parent  string
+	due     string
+	labels  []string
+}
+
+// taskIndexes are the secondary indexes of a TaskManager.
+type taskIndexes struct {
+	byContent taskIndex
+	byProject taskIndex
+	bySection taskIndex
+	byParent  taskIndex
+	byDue     taskIndex
+	byLabel   taskIndex
+	keys      map[string]indexKeys
+	// search indexes the text of tasks, commentSearch that of their
+	// comments, see Search.
+	search        *searchIndex
+	commentSearch *searchIndex
+}
+
+func newTaskIndexes() taskIndexes {
+	return taskIndexes{
+		byContent: make(taskIndex),
+		byProject: make(taskIndex),
+		bySection: make(taskIndex),
+		byParent:  make(taskIndex),
+		byDue:     make(taskIndex),
+		byLabel:   make(taskIndex),
+		keys:      make(map[string]indexKeys),
+
+		search:        newSearchIndex(),
+		commentSearch: newSearchIndex(),
+	}
+}
+
+// dueKey returns the local date a task is due on, or "" if it has none.
+func dueKey(task *Task) string {
+	if task.Due == nil {
+		return ""
+	}
+	due := *task.Due
+	if due.ParsedDate.IsZero() {
+		var err error
+		if due.ParsedDate, err = parseDueDate(due.Date, due.Timezone); err != nil {
+			return ""
+		}
+	}
+	return due.Local().Format(time.DateOnly)
+}
+
+func (ix *taskIndexes) add(task *Task) {
+	keys := indexKeys{
+		content: task.Content,
+		project: task.ProjectID,
+		section: task.SectionID,
+		parent:  task.ParentID,
+		due:     dueKey(task),
+		labels:  append([]string{}, task.Labels...),
+	}
+	ix.keys[task.ID] = keys
+
+	ix.byContent.add(keys.content, task)
+	ix.byProject.add(keys.project, task)
+	ix.bySection.add(keys.section, task)
+	ix.byParent.add(keys.parent, task)
+	ix.byDue.add(keys.due, task)
+	for _, label := range keys.labels {
+		ix.byLabel.add(label, task)
+	}
+	ix.search.indexTask(task)
+}
+
+func (ix *taskIndexes) remove(id string) {
+	keys, exists := ix.keys[id]
+	if !exists {
+		return
+	}
+	delete(ix.keys, id)
+
+	ix.byContent.remove(keys.content, id)
+	ix.byProject.remove(keys.project, id)
+	ix.bySection.remove(keys.section, id)
+	ix.byParent.remove(keys.parent, id)
+	ix.byDue.remove(keys.due, id)
+	for _, label := range keys.labels {
+		ix.byLabel.remove(label, id)
+	}
+	ix.search.remove(id)
+}
+
+// Reindex updates the indexes after fields of a cached task changed.
+// Changes made through the library do this automatically; callers that
+// modify task fields directly need to call it themselves.
+func (t *TaskManager) Reindex(task *Task) {
+	t.indexes.remove(task.ID)
+	if t.tasks[task.ID] == task {
+		t.indexes.add(task)
+	}
+}
+
+// ByProject returns the tasks of a project ordered by child order.
+func (t *TaskManager) ByProject(projectID string) []*Task {
+	return t.indexes.byProject.get(projectID)
+}
+
+// BySection returns the tasks of a section ordered by child order.
+func (t *TaskManager) BySection(sectionID string) []*Task {
+	return t.indexes.bySection.get(sectionID)
+}
+
+// ByLabel returns the tasks having label ordered by child order.
+func (t *TaskManager) ByLabel(label string) []*Task {
+	return t.indexes.byLabel.get(label)
+}
+
+// DueOn returns the tasks due on the local date of day.
+func (t *TaskManager) DueOn(day time.Time) []*Task {
+	return t.indexes.byDue.get(day.In(time.Local).Format(time.DateOnly))
+}
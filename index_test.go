@@ -0,0 +1,114 @@
+package godoist
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTaskIndexes(t *testing.T) {
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{
+		{ID: "1", Content: "Buy milk", ProjectID: "100", Labels: []string{"errands"}, Due: &Due{Date: "2026-03-02"}},
+		{ID: "2", Content: "Buy milk", ProjectID: "200", SectionID: "s1"},
+		{ID: "3", Content: "Pack", ProjectID: "100", ParentID: "1"},
+	})
+	td.Tasks.Update([]Task{{ID: "1", Content: "Buy bread", ProjectID: "100", Due: &Due{Date: "2026-03-02"}}})
+
+	if got := td.Tasks.GetByName("Buy milk"); len(got) != 1 || got[0].ID != "2" {
+		t.Errorf("expected only task 2 named 'Buy milk', got %v", got)
+	}
+	if got := td.Tasks.ByProject("100"); len(got) != 2 {
+		t.Errorf("expected 2 tasks in project 100, got %v", got)
+	}
+	if got := td.Tasks.BySection("s1"); len(got) != 1 || got[0].ID != "2" {
+		t.Errorf("expected task 2 in section s1, got %v", got)
+	}
+	if got := td.Tasks.ByLabel("errands"); len(got) != 0 {
+		t.Errorf("expected label index to drop removed label, got %v", got)
+	}
+	due, _ := time.ParseInLocation(time.DateOnly, "2026-03-02", time.Local)
+	if got := td.Tasks.DueOn(due); len(got) != 1 || got[0].ID != "1" {
+		t.Errorf("expected task 1 due on 2026-03-02, got %v", got)
+	}
+	if got := td.Tasks.Get("1").GetChildren(); len(got) != 1 || got[0].ID != "3" {
+		t.Errorf("expected task 3 as child of 1, got %v", got)
+	}
+
+	task := td.Tasks.Get("2")
+	task.ProjectID = "100"
+	td.Tasks.Reindex(task)
+	if got := td.Tasks.ByProject("200"); len(got) != 0 {
+		t.Errorf("expected project 200 to be empty after reindex, got %v", got)
+	}
+}
+
+// benchmarkManager builds a manager with n tasks spread over 100 projects,
+// every other task being a subtask of the task before it.
+func benchmarkManager(n int) *TaskManager {
+	manager := NewTaskManager(nil)
+	tasks := make([]Task, 0, n)
+	for i := 0; i < n; i++ {
+		task := Task{ID: fmt.Sprint(i), ProjectID: fmt.Sprint(i % 100), ChildOrder: i}
+		if i%2 == 1 {
+			task.ParentID = fmt.Sprint(i - 1)
+		}
+		tasks = append(tasks, task)
+	}
+	manager.Update(tasks)
+	return manager
+}
+
+// BenchmarkProjectTasksScan is the linear scan Project.GetTasks used to
+// do, kept as a baseline for BenchmarkProjectTasksIndexed.
+func BenchmarkProjectTasksScan(b *testing.B) {
+	manager := benchmarkManager(20000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var tasks []*Task
+		for _, task := range manager.tasks {
+			if task.ProjectID == "42" {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+}
+
+func BenchmarkProjectTasksIndexed(b *testing.B) {
+	manager := benchmarkManager(20000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		manager.ByProject("42")
+	}
+}
+
+// BenchmarkGetChildrenScan is the linear scan Task.GetChildren used to do,
+// kept as a baseline for BenchmarkGetChildrenIndexed.
+func BenchmarkGetChildrenScan(b *testing.B) {
+	manager := benchmarkManager(20000)
+	parent := manager.Get("4242")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var tasks []*Task
+		for _, task := range manager.tasks {
+			if task.ParentID == parent.ID {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+}
+
+func BenchmarkGetChildrenIndexed(b *testing.B) {
+	manager := benchmarkManager(20000)
+	parent := manager.Get("4242")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parent.GetChildren()
+	}
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		benchmarkManager(20000)
+	}
+}
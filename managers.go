@@ -1,8 +1,8 @@
 package godoist
 
 import (
-	"encoding/json"
 	"fmt"
+	"slices"
 )
 
 type Manager struct {
@@ -14,20 +14,78 @@ type TaskManager struct {
 	api     *TodoistAPI
 	tasks   map[string]*Task
 	Manager *Manager
+
+	// contexts caches context comments by task ID and namespace. It is
+	// filled when cacheContexts is set, i.e. when the client persists its
+	// state, and only consulted when readCachedContexts is set.
+	contexts           map[string]map[string]Comment
+	cacheContexts      bool
+	readCachedContexts bool
+	// validators check context writes by namespace.
+	validators map[string]ContextValidator
+	// keys encrypts contexts when set, see Todoist.EncryptContexts.
+	keys KeyProvider
+	// history records context writes when set.
+	history *historyConfig
+	// contextStore persists task contexts, see Todoist.UseContextStore.
+	contextStore ContextStore
+
+	// journal receives writes made while offline, see EnableOffline.
+	journal *Journal
+	offline bool
+
+	indexes taskIndexes
 }
 
 func NewTaskManager(api *TodoistAPI) *TaskManager {
-	return &TaskManager{api: api, tasks: make(map[string]*Task)}
+	return &TaskManager{
+		api:          api,
+		tasks:        make(map[string]*Task),
+		contexts:     make(map[string]map[string]Comment),
+		validators:   make(map[string]ContextValidator),
+		contextStore: CommentContextStore{},
+		indexes:      newTaskIndexes(),
+	}
 }
 
 func (t *TaskManager) addTask(task Task) {
-	t.tasks[task.ID] = &task
+	t.put(&task)
+}
+
+// put stores task in the cache and its indexes, replacing any task with
+// the same ID.
+func (t *TaskManager) put(task *Task) {
+	t.indexes.remove(task.ID)
+	t.tasks[task.ID] = task
+	t.indexes.add(task)
+}
+
+func (t *TaskManager) removeTask(id string) {
+	t.indexes.remove(id)
+	t.indexes.commentSearch.remove(id)
+	delete(t.tasks, id)
+	delete(t.contexts, id)
 }
 
+// All returns the cached tasks grouped by project in project order.
+// Within a project every task is followed by its subtasks, siblings being
+// ordered by child order. Orphans and tasks whose parents form a cycle
+// follow the other tasks of their project.
 func (t *TaskManager) All() []*Task {
 	var tasks = make([]*Task, 0, len(t.tasks))
-	for _, task := range t.tasks {
-		tasks = append(tasks, task)
+	tree := t.Tree()
+	tops := slices.Concat(tree.roots, tree.Orphans, tree.Cyclic)
+	slices.SortStableFunc(tops, tree.byProject)
+	for _, task := range tops {
+		if tree.Depth(task) < 0 {
+			// The subtasks of a cyclic task are cyclic themselves.
+			tasks = append(tasks, task)
+			continue
+		}
+		tree.walk([]*Task{task}, 0, func(task *Task, depth int) bool {
+			tasks = append(tasks, task)
+			return true
+		})
 	}
 	return tasks
 }
@@ -39,6 +97,37 @@ func (t *TaskManager) Update(tasks []Task) {
 	}
 }
 
+// merge applies the tasks of a Sync API response. Deleted and completed
+// tasks are removed, and cached contexts of changed tasks are dropped.
+func (t *TaskManager) merge(tasks []Task) {
+	for _, task := range tasks {
+		if task.IsDeleted || task.Checked {
+			t.removeTask(task.ID)
+			continue
+		}
+		if old, exists := t.tasks[task.ID]; exists && (old.NoteCount != task.NoteCount || old.UpdatedAt != task.UpdatedAt) {
+			delete(t.contexts, task.ID)
+		}
+		task.manager = t
+		t.addTask(task)
+	}
+}
+
+// replace swaps the cached tasks for the result of a full sync, dropping
+// tasks that are no longer present on the server.
+func (t *TaskManager) replace(tasks []Task) {
+	seen := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		seen[task.ID] = true
+	}
+	for id := range t.tasks {
+		if !seen[id] {
+			t.removeTask(id)
+		}
+	}
+	t.merge(tasks)
+}
+
 func (t *TaskManager) Get(id string) *Task {
 	task, exists := t.tasks[id]
 	if !exists {
@@ -48,13 +137,7 @@ func (t *TaskManager) Get(id string) *Task {
 }
 
 func (t *TaskManager) GetByName(name string) []*Task {
-	var tasks = make([]*Task, 0)
-	for _, task := range t.tasks {
-		if task.Content == name {
-			tasks = append(tasks, task)
-		}
-	}
-	return tasks
+	return t.indexes.byContent.get(name)
 }
 
 func (t *TaskManager) String() string {
@@ -66,9 +149,11 @@ func (t *TaskManager) Len() int {
 }
 
 func (t *TaskManager) UpdateTask(task Task) {
-	t.tasks[task.ID] = &task
+	task.manager = t
+	t.addTask(task)
 }
 
+// AddTask creates a task on the server from the fields set on task.
 func (t *TaskManager) AddTask(task Task) error {
 	if task.ID != "" {
 		if _, exists := t.tasks[task.ID]; exists {
@@ -76,46 +161,12 @@ func (t *TaskManager) AddTask(task Task) error {
 		}
 	}
 
-	taskJSON, err := json.Marshal(task)
-	if err != nil {
-		return err
-	}
-	var taskMap map[string]interface{}
-	if err := json.Unmarshal(taskJSON, &taskMap); err != nil {
-		return err
-	}
-	// Remove zero/empty values that shouldn't be sent
-	for key, value := range taskMap {
-		if value == nil || value == "" || value == 0.0 {
-			delete(taskMap, key)
-		}
-	}
-
-	created, err := t.api.CreateTask(taskMap)
-	if err != nil {
-		return err
-	}
-
-	created.manager = t
-	t.tasks[created.ID] = created
-	// Update the caller's task with the real ID
-	task.ID = created.ID
-	return nil
+	_, err := t.CreateWith(optionsFromTask(task))
+	return err
 }
 
 func (t *TaskManager) Create(content string) (*Task, error) {
-	task := Task{Content: content, manager: t}
-	err := t.AddTask(task)
-	if err != nil {
-		return nil, err
-	}
-	// Return the task from the map (has real ID from API)
-	for _, stored := range t.tasks {
-		if stored.Content == content {
-			return stored, nil
-		}
-	}
-	return nil, fmt.Errorf("task created but not found in store")
+	return t.CreateWith(CreateTaskOptions{Content: content})
 }
 
 type ProjectManager struct {
@@ -140,12 +191,45 @@ func (p *ProjectManager) Update(projects []Project) {
 	}
 }
 
+// merge applies the projects of a Sync API response, removing deleted
+// projects.
+func (p *ProjectManager) merge(projects []Project) {
+	for _, project := range projects {
+		if project.IsDeleted {
+			delete(p.projects, project.ID)
+			continue
+		}
+		project.Manager = p
+		p.projects[project.ID] = &project
+	}
+}
+
+// replace swaps the cached projects for the result of a full sync.
+func (p *ProjectManager) replace(projects []Project) {
+	p.projects = make(map[string]*Project, len(projects))
+	p.merge(projects)
+}
+
+// All returns the cached projects as they appear in Todoist: every project
+// followed by its subprojects, siblings being ordered by child order.
 func (p *ProjectManager) All() []*Project {
 	var projects = make([]*Project, 0, len(p.projects))
-	for _, project := range p.projects {
+	seen := make(map[string]bool, len(p.projects))
+	p.Tree().Walk(func(project *Project, depth int) bool {
 		projects = append(projects, project)
+		seen[project.ID] = true
+		return true
+	})
+
+	// Projects whose parents form a cycle are not reachable from any root.
+	var cyclic []*Project
+	for _, project := range p.projects {
+		if !seen[project.ID] {
+			cyclic = append(cyclic, project)
+		}
 	}
-	return projects
+	sortProjects(cyclic)
+	return append(projects, cyclic...)
 }
 
 func (p *ProjectManager) Get(id string) *Project {
@@ -1,5 +1,10 @@
 package godoist
 
+import (
+	"fmt"
+	"slices"
+)
+
 // MoveTask moves a task to a different project and/or parent.
 func (t *TodoistAPI) MoveTask(taskID, projectID, parentID string) error {
 	fields := map[string]interface{}{
@@ -15,3 +20,174 @@ func (t *TodoistAPI) MoveTask(taskID, projectID, parentID string) error {
 func (t *TodoistAPI) DeleteTask(id string) error {
 	return t.doDelete("/tasks/" + id)
 }
+
+// moveCommand builds an item_move command placing the task below parentID,
+// or at the top of sectionID or projectID if it has no parent.
+func moveCommand(taskID, parentID, sectionID, projectID string) Command {
+	args := map[string]interface{}{"id": taskID}
+	switch {
+	case parentID != "":
+		args["parent_id"] = parentID
+	case sectionID != "":
+		args["section_id"] = sectionID
+	default:
+		args["project_id"] = projectID
+	}
+	return NewCommand("item_move", args)
+}
+
+// reorderCommand builds an item_reorder command giving the tasks child
+// orders matching their position.
+func reorderCommand(tasks []*Task) Command {
+	items := make([]map[string]interface{}, 0, len(tasks))
+	for i, task := range tasks {
+		items = append(items, map[string]interface{}{"id": task.ID, "child_order": i + 1})
+	}
+	return NewCommand("item_reorder", map[string]interface{}{"items": items})
+}
+
+// setPlacement updates the cached position of the task and moves its
+// subtasks along.
+func (t *Task) setPlacement(parentID, sectionID, projectID string) {
+	t.ParentID, t.SectionID, t.ProjectID = parentID, sectionID, projectID
+	t.manager.Reindex(t)
+	for _, child := range t.Descendants() {
+		child.SectionID, child.ProjectID = sectionID, projectID
+		t.manager.Reindex(child)
+	}
+}
+
+// siblings returns the tasks sharing the parent, or section and project
+// for top-level tasks, with the task ordered by child order.
+func (t *TaskManager) siblings(task *Task) []*Task {
+	if task.ParentID != "" {
+		return t.indexes.byParent.get(task.ParentID)
+	}
+	var siblings []*Task
+	for _, other := range t.indexes.byProject.get(task.ProjectID) {
+		if other.ParentID == "" && other.SectionID == task.SectionID {
+			siblings = append(siblings, other)
+		}
+	}
+	return siblings
+}
+
+// MoveToSection moves the task to the top level of a section, which may
+// belong to another project.
+func (t *Task) MoveToSection(sectionID string) error {
+	projectID, err := t.manager.sectionProject(sectionID)
+	if err != nil {
+		return err
+	}
+	if err := t.manager.execute(t, moveCommand(t.ID, "", sectionID, "")); err != nil {
+		return err
+	}
+	t.setPlacement("", sectionID, projectID)
+	t.manager.refresh(t)
+	return nil
+}
+
+// MoveToRoot moves the task to the top level of its project, outside of
+// any section.
+func (t *Task) MoveToRoot() error {
+	if err := t.manager.execute(t, moveCommand(t.ID, "", "", t.ProjectID)); err != nil {
+		return err
+	}
+	t.setPlacement("", "", t.ProjectID)
+	t.manager.refresh(t)
+	return nil
+}
+
+// MoveBefore places the task right before other, moving it to the parent,
+// section and project of other if necessary.
+func (t *Task) MoveBefore(other *Task) error {
+	return t.moveNextTo(other, 0)
+}
+
+// MoveAfter places the task right after other, moving it to the parent,
+// section and project of other if necessary.
+func (t *Task) MoveAfter(other *Task) error {
+	return t.moveNextTo(other, 1)
+}
+
+func (t *Task) moveNextTo(other *Task, offset int) error {
+	if other.ID == t.ID {
+		return fmt.Errorf("cannot move task %s next to itself", t.ID)
+	}
+	for _, ancestor := range other.Ancestors() {
+		if ancestor.ID == t.ID {
+			return fmt.Errorf("cannot move task %s next to its subtask %s", t.ID, other.ID)
+		}
+	}
+
+	siblings := make([]*Task, 0)
+	for _, sibling := range t.manager.siblings(other) {
+		if sibling.ID == t.ID {
+			continue
+		}
+		siblings = append(siblings, sibling)
+		if sibling.ID == other.ID {
+			siblings = slices.Insert(siblings, len(siblings)-1+offset, t)
+		}
+	}
+
+	var cmds []Command
+	moved := t.ParentID != other.ParentID || t.SectionID != other.SectionID || t.ProjectID != other.ProjectID
+	if moved {
+		cmds = append(cmds, moveCommand(t.ID, other.ParentID, other.SectionID, other.ProjectID))
+	}
+	cmds = append(cmds, reorderCommand(siblings))
+	if err := t.manager.execute(t, cmds...); err != nil {
+		return err
+	}
+
+	if moved {
+		t.setPlacement(other.ParentID, other.SectionID, other.ProjectID)
+	}
+	t.manager.setChildOrders(siblings)
+	t.manager.refresh(siblings...)
+	return nil
+}
+
+// setChildOrders updates the cached child order of tasks to their position.
+func (t *TaskManager) setChildOrders(tasks []*Task) {
+	for i, task := range tasks {
+		task.ChildOrder = i + 1
+		t.Reindex(task)
+	}
+}
+
+// ReorderTasks gives the tasks child orders matching their position in
+// the slice, typically used with tasks sharing a parent or section.
+func (t *TaskManager) ReorderTasks(tasks []*Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	if err := t.execute(nil, reorderCommand(tasks)); err != nil {
+		return err
+	}
+	t.setChildOrders(tasks)
+	t.refresh(tasks...)
+	return nil
+}
+
+// UpdateDayOrders sets the order of tasks in the Today and Upcoming views
+// to their position in the slice.
+func (t *TaskManager) UpdateDayOrders(tasks []*Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	orders := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		orders[task.ID] = i + 1
+	}
+	cmd := NewCommand("item_update_day_orders", map[string]interface{}{"ids_to_orders": orders})
+	if err := t.execute(nil, cmd); err != nil {
+		return err
+	}
+	for i, task := range tasks {
+		task.DayOrder = i + 1
+	}
+	t.refresh(tasks...)
+	return nil
+}
@@ -115,3 +115,96 @@ func TestDeleteTask(t *testing.T) {
 		t.Errorf("expected path '/tasks/task-99', got %q", deletedPath)
 	}
 }
+
+func TestMoveAndReorder(t *testing.T) {
+	var received []Command
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sync", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload struct {
+			Commands []Command `json:"commands"`
+		}
+		json.Unmarshal(body, &payload)
+		received = payload.Commands
+
+		status := map[string]string{}
+		for _, cmd := range payload.Commands {
+			status[cmd.UUID] = "ok"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"sync_status": status})
+	})
+	mux.HandleFunc("GET /sections/{id}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Section{ID: r.PathValue("id"), ProjectID: "200"})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{
+		{ID: "a", ProjectID: "100", SectionID: "s1", ChildOrder: 1},
+		{ID: "b", ProjectID: "100", SectionID: "s1", ChildOrder: 2},
+		{ID: "c", ProjectID: "100", ChildOrder: 1},
+		{ID: "c1", ProjectID: "100", ParentID: "c", ChildOrder: 1},
+	})
+
+	c := td.Tasks.Get("c")
+	if err := c.MoveAfter(td.Tasks.Get("a")); err != nil {
+		t.Fatalf("MoveAfter() returned error: %v", err)
+	}
+	if len(received) != 2 || received[0].Type != "item_move" || received[0].Args["section_id"] != "s1" {
+		t.Fatalf("expected item_move to s1 followed by a reorder, got %+v", received)
+	}
+	if received[1].Type != "item_reorder" {
+		t.Errorf("expected item_reorder, got %s", received[1].Type)
+	}
+	if c.SectionID != "s1" || c.ChildOrder != 2 || td.Tasks.Get("b").ChildOrder != 3 {
+		t.Errorf("expected c between a and b in s1, got section %q order %d", c.SectionID, c.ChildOrder)
+	}
+	if td.Tasks.Get("c1").SectionID != "s1" {
+		t.Error("expected subtask to move along with its parent")
+	}
+
+	if err := c.MoveToRoot(); err != nil {
+		t.Fatalf("MoveToRoot() returned error: %v", err)
+	}
+	if received[0].Args["project_id"] != "100" || c.SectionID != "" || td.Tasks.Get("c1").SectionID != "" {
+		t.Errorf("expected c and its subtask to leave the section, got %+v", received[0].Args)
+	}
+
+	if err := td.Tasks.ReorderTasks([]*Task{td.Tasks.Get("b"), td.Tasks.Get("a")}); err != nil {
+		t.Fatalf("ReorderTasks() returned error: %v", err)
+	}
+	if got := taskIDs(td.Tasks.BySection("s1")); got != "b a" {
+		t.Errorf("expected section s1 ordered 'b a', got %q", got)
+	}
+
+	if err := td.Tasks.UpdateDayOrders([]*Task{c}); err != nil {
+		t.Fatalf("UpdateDayOrders() returned error: %v", err)
+	}
+	if received[0].Type != "item_update_day_orders" || c.DayOrder != 1 {
+		t.Errorf("expected day order update, got %+v", received[0])
+	}
+
+	if err := c.MoveBefore(c); err == nil {
+		t.Error("expected error moving a task next to itself")
+	}
+	if err := c.MoveAfter(td.Tasks.Get("c1")); err == nil {
+		t.Error("expected error moving a task next to its subtask")
+	}
+
+	if err := c.MoveToSection("s2"); err != nil {
+		t.Fatalf("MoveToSection() returned error: %v", err)
+	}
+	if received[0].Args["section_id"] != "s2" || c.SectionID != "s2" || c.ProjectID != "200" {
+		t.Errorf("expected c in section s2 of project 200, got section %q project %q", c.SectionID, c.ProjectID)
+	}
+	if td.Tasks.Get("c1").ProjectID != "200" || len(td.Tasks.ByProject("100")) != 2 {
+		t.Error("expected subtask to move to the project of the section")
+	}
+}
@@ -0,0 +1,400 @@
+package godoist
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JournalEntry is a write made while offline, recorded as a Sync API
+// command together with the task state it was based on.
+type JournalEntry struct {
+	Command Command `json:"command"`
+	TaskID  string  `json:"task_id"`
+	// BaseUpdatedAt is the UpdatedAt of the task when the write was made.
+	// It is empty for tasks created offline.
+	BaseUpdatedAt string    `json:"base_updated_at"`
+	QueuedAt      time.Time `json:"queued_at"`
+}
+
+// Journal is an append-only file of writes waiting to be replayed.
+type Journal struct {
+	Path string
+}
+
+// NewJournal creates a journal backed by the file at path
+func NewJournal(path string) *Journal {
+	return &Journal{Path: path}
+}
+
+// Append durably adds an entry to the journal.
+func (j *Journal) Append(entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Entries returns all journaled entries in the order they were written.
+func (j *Journal) Entries() ([]JournalEntry, error) {
+	f, err := os.Open(j.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse journal %q: %w", j.Path, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// rewrite atomically replaces the journal with the given entries.
+func (j *Journal) rewrite(entries []JournalEntry) error {
+	if len(entries) == 0 {
+		err := os.Remove(j.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.Path), filepath.Base(j.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	encoder := json.NewEncoder(tmp)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.Path)
+}
+
+// Conflict describes a journaled write to a task that was changed on the
+// server after the write was made. Remote is nil if the task no longer
+// exists on the server.
+type Conflict struct {
+	Entries []JournalEntry
+	Local   *Task
+	Remote  *Task
+}
+
+type Resolution int
+
+const (
+	// KeepLocal replays the journaled writes, overwriting server changes.
+	KeepLocal Resolution = iota
+	// KeepRemote drops the journaled writes for the task.
+	KeepRemote
+)
+
+// ConflictResolver decides how a conflict found during replay is handled.
+type ConflictResolver func(conflict Conflict) Resolution
+
+// isNetworkError reports whether err means the API could not be reached,
+// as opposed to the API rejecting the request.
+func isNetworkError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// EnableOffline journals writes to journal whenever the API cannot be
+// reached, applying them to the local managers immediately. Online writes
+// that do not return the task, such as moves and Close, are followed by a
+// request for it to keep conflict detection accurate.
+func (t *Todoist) EnableOffline(journal *Journal) {
+	t.Tasks.journal = journal
+}
+
+// SetOffline forces offline mode on or off. While offline every write is
+// journaled without contacting the API. It has no effect unless
+// EnableOffline was called.
+func (t *Todoist) SetOffline(offline bool) {
+	t.Tasks.offline = offline && t.Tasks.journal != nil
+}
+
+// IsOffline reports whether writes are currently being journaled.
+func (t *Todoist) IsOffline() bool {
+	return t.Tasks.offline
+}
+
+// submit performs a write through online, or journals it as Sync API
+// commands if the client is offline or the API turns out to be unreachable.
+// Journaled commands are checked for conflicts against task, which may be
+// nil for commands affecting several tasks.
+func (t *TaskManager) submit(task *Task, online func() error, cmds ...Command) error {
+	if t.journal == nil {
+		return online()
+	}
+	if !t.offline {
+		err := online()
+		if !isNetworkError(err) {
+			return err
+		}
+		t.api.logger.Warn("API unreachable, switching to offline mode", "error", err)
+		t.offline = true
+	}
+	return t.queue(task, cmds...)
+}
+
+// queue journals commands concerning task, which may be nil, for replay.
+func (t *TaskManager) queue(task *Task, cmds ...Command) error {
+	for _, cmd := range cmds {
+		entry := JournalEntry{Command: cmd, QueuedAt: time.Now()}
+		if task != nil {
+			entry.TaskID, entry.BaseUpdatedAt = task.ID, task.UpdatedAt
+		}
+		if cmd.TempID != "" {
+			entry.TaskID = cmd.TempID
+		}
+		if err := t.journal.Append(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execute runs Sync API commands in a single request, journaling them
+// while offline.
+func (t *TaskManager) execute(task *Task, cmds ...Command) error {
+	return t.submit(task, func() error {
+		resp, err := t.api.ExecuteCommands(cmds)
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, cmd := range cmds {
+			errs = append(errs, resp.Err(cmd.UUID))
+		}
+		return errors.Join(errs...)
+	}, cmds...)
+}
+
+// refresh records the UpdatedAt the server assigned to tasks after an
+// online write whose response does not include them, so that later
+// offline writes are not mistaken for conflicts with this client's own
+// changes. It costs a request per task and is skipped unless writes are
+// being journaled.
+func (t *TaskManager) refresh(tasks ...*Task) {
+	if t.journal == nil || t.offline {
+		return
+	}
+	for _, task := range tasks {
+		remote, err := t.api.GetTask(task.ID)
+		if err != nil {
+			t.api.logger.Warn("could not refresh task after write", "task", task.ID, "error", err)
+			continue
+		}
+		task.UpdatedAt = remote.UpdatedAt
+	}
+}
+
+// ReplayError reports the journaled writes the server rejected during
+// Replay. They are removed from the journal as sending them again would
+// fail the same way.
+type ReplayError struct {
+	Rejected []JournalEntry
+	// Errs holds the reason for every rejected entry, by index.
+	Errs []error
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("%d journaled writes rejected: %v", len(e.Rejected), errors.Join(e.Errs...))
+}
+
+func (e *ReplayError) Unwrap() []error {
+	return e.Errs
+}
+
+// Replay sends all journaled writes to the API. Writes to tasks that were
+// changed on the server since they were made are passed to resolve first;
+// a nil resolver keeps the local writes. Entries that could not be sent
+// stay in the journal, entries the server rejected are discarded and
+// reported as a *ReplayError.
+func (t *Todoist) Replay(resolve ConflictResolver) error {
+	journal := t.Tasks.journal
+	if journal == nil {
+		return nil
+	}
+	entries, err := journal.Entries()
+	if err != nil {
+		return err
+	}
+
+	dropped, err := t.findConflicts(entries, resolve)
+	if err != nil {
+		return err
+	}
+
+	var (
+		errs     []error
+		pending  []JournalEntry
+		rejected ReplayError
+		tempIDs  = make(map[string]string)
+	)
+	for len(entries) > 0 {
+		n := min(len(entries), commandBatchSize)
+		batch := entries[:n]
+		entries = entries[n:]
+
+		commands := make([]Command, 0, len(batch))
+		sent := make([]JournalEntry, 0, len(batch))
+		for _, entry := range batch {
+			if dropped[entry.TaskID] {
+				continue
+			}
+			entry.Command.Args = resolveTempIDs(entry.Command.Args, tempIDs)
+			commands = append(commands, entry.Command)
+			sent = append(sent, entry)
+		}
+		if len(commands) == 0 {
+			continue
+		}
+
+		resp, err := t.API.ExecuteCommands(commands)
+		if err != nil {
+			pending = append(append(pending, sent...), entries...)
+			errs = append(errs, err)
+			break
+		}
+		for tempID, id := range resp.TempIDMapping {
+			tempIDs[tempID] = id
+			t.Tasks.rekey(tempID, id)
+		}
+		for _, entry := range sent {
+			if err := resp.Err(entry.Command.UUID); err != nil {
+				rejected.Rejected = append(rejected.Rejected, entry)
+				rejected.Errs = append(rejected.Errs, err)
+			}
+		}
+	}
+	if len(rejected.Rejected) > 0 {
+		errs = append(errs, &rejected)
+	}
+
+	if err := journal.rewrite(pending); err != nil {
+		errs = append(errs, err)
+	}
+	if len(pending) == 0 {
+		t.Tasks.offline = false
+	}
+	return errors.Join(errs...)
+}
+
+// findConflicts compares every journaled task with its server version and
+// returns the IDs of tasks whose writes should be dropped.
+func (t *Todoist) findConflicts(entries []JournalEntry, resolve ConflictResolver) (map[string]bool, error) {
+	byTask := make(map[string][]JournalEntry)
+	var order []string
+	for _, entry := range entries {
+		if entry.BaseUpdatedAt == "" {
+			continue
+		}
+		if _, seen := byTask[entry.TaskID]; !seen {
+			order = append(order, entry.TaskID)
+		}
+		byTask[entry.TaskID] = append(byTask[entry.TaskID], entry)
+	}
+
+	dropped := make(map[string]bool)
+	for _, id := range order {
+		taskEntries := byTask[id]
+		remote, err := t.API.GetTask(id)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == 404 {
+			remote, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if remote != nil && remote.UpdatedAt == taskEntries[0].BaseUpdatedAt {
+			continue
+		}
+
+		conflict := Conflict{Entries: taskEntries, Local: t.Tasks.Get(id), Remote: remote}
+		if resolve != nil && resolve(conflict) == KeepRemote {
+			dropped[id] = true
+			if remote != nil {
+				t.Tasks.Update([]Task{*remote})
+			} else {
+				t.Tasks.removeTask(id)
+			}
+		}
+	}
+	return dropped, nil
+}
+
+// resolveTempIDs replaces temporary IDs of tasks created offline with the
+// real IDs assigned by the server.
+func resolveTempIDs(args map[string]interface{}, tempIDs map[string]string) map[string]interface{} {
+	for key, value := range args {
+		if id, ok := value.(string); ok {
+			if real, mapped := tempIDs[id]; mapped {
+				args[key] = real
+			}
+		}
+	}
+	return args
+}
+
+// rekey moves a task created offline from its temporary to its real ID.
+func (t *TaskManager) rekey(tempID, id string) {
+	task, exists := t.tasks[tempID]
+	if !exists {
+		return
+	}
+	t.indexes.remove(tempID)
+	delete(t.tasks, tempID)
+	task.ID = id
+	t.put(task)
+	for _, child := range t.indexes.byParent.get(tempID) {
+		child.ParentID = id
+		t.Reindex(child)
+	}
+}
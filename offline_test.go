@@ -0,0 +1,234 @@
+package godoist
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestOfflineJournalAndReplay(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	orig := APIURL
+	APIURL = down.URL
+	defer func() { APIURL = orig }()
+
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	td := NewTodoist("test-token")
+	td.EnableOffline(journal)
+	td.Tasks.Update([]Task{
+		{ID: "1", Content: "Buy milk", UpdatedAt: "2026-03-01T10:00:00Z"},
+		{ID: "2", Content: "Call mom", UpdatedAt: "2026-03-01T10:00:00Z"},
+	})
+
+	if err := td.Tasks.Get("1").Update("content", "Buy oat milk"); err != nil {
+		t.Fatalf("Update() while unreachable returned error: %v", err)
+	}
+	if !td.IsOffline() {
+		t.Fatal("expected client to switch to offline mode")
+	}
+	if err := td.Tasks.Get("2").Close(); err != nil {
+		t.Fatalf("Close() while offline returned error: %v", err)
+	}
+	created, err := td.Tasks.Create("Write report")
+	if err != nil {
+		t.Fatalf("Create() while offline returned error: %v", err)
+	}
+	tempID := created.ID
+
+	if td.Tasks.Get("1").Content != "Buy oat milk" || !td.Tasks.Get("2").Checked {
+		t.Error("expected offline writes to be applied locally")
+	}
+	entries, err := journal.Entries()
+	if err != nil {
+		t.Fatalf("Entries() returned error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 journaled entries, got %d", len(entries))
+	}
+
+	var received []Command
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		updatedAt := "2026-03-01T10:00:00Z"
+		if r.PathValue("id") == "2" {
+			updatedAt = "2026-03-01T11:00:00Z"
+		}
+		json.NewEncoder(w).Encode(Task{ID: r.PathValue("id"), Content: "remote", UpdatedAt: updatedAt})
+	})
+	mux.HandleFunc("POST /sync", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload struct {
+			Commands []Command `json:"commands"`
+		}
+		json.Unmarshal(body, &payload)
+		received = payload.Commands
+
+		status := map[string]string{}
+		mapping := map[string]string{}
+		for _, cmd := range payload.Commands {
+			status[cmd.UUID] = "ok"
+			if cmd.TempID != "" {
+				mapping[cmd.TempID] = "99"
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sync_status":     status,
+			"temp_id_mapping": mapping,
+		})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	APIURL = srv.URL
+
+	var conflicts []Conflict
+	err = td.Replay(func(c Conflict) Resolution {
+		conflicts = append(conflicts, c)
+		return KeepRemote
+	})
+	if err != nil {
+		t.Fatalf("Replay() returned error: %v", err)
+	}
+
+	if len(conflicts) != 1 || conflicts[0].Remote.ID != "2" {
+		t.Fatalf("expected a single conflict on task 2, got %+v", conflicts)
+	}
+	if len(received) != 2 || received[0].Type != "item_update" || received[1].Type != "item_add" {
+		t.Fatalf("expected item_update and item_add to be replayed, got %+v", received)
+	}
+	if td.Tasks.Get(tempID) != nil || td.Tasks.Get("99") == nil {
+		t.Error("expected created task to be re-keyed to its real ID")
+	}
+	if td.Tasks.Get("2").Checked {
+		t.Error("expected remote version to win for task 2")
+	}
+	if td.IsOffline() {
+		t.Error("expected client to be online after replay")
+	}
+	if entries, _ := journal.Entries(); len(entries) != 0 {
+		t.Errorf("expected empty journal after replay, got %d entries", len(entries))
+	}
+}
+
+func TestReplayRejected(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	orig := APIURL
+	APIURL = down.URL
+	defer func() { APIURL = orig }()
+
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal.jsonl"))
+	td := NewTodoist("test-token")
+	td.EnableOffline(journal)
+	td.Tasks.Update([]Task{{ID: "1", Content: "Buy milk"}, {ID: "2", Content: "Call mom"}})
+
+	if err := td.Tasks.Get("1").Update("content", "Buy oat milk"); err != nil {
+		t.Fatalf("Update() while unreachable returned error: %v", err)
+	}
+	if err := td.Tasks.Get("2").Update("content", "Call dad"); err != nil {
+		t.Fatalf("Update() while offline returned error: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sync", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload struct {
+			Commands []Command `json:"commands"`
+		}
+		json.Unmarshal(body, &payload)
+
+		status := map[string]interface{}{}
+		for _, cmd := range payload.Commands {
+			status[cmd.UUID] = "ok"
+			if cmd.Args["id"] == "2" {
+				status[cmd.UUID] = map[string]interface{}{"error": "Item not found", "error_code": 22}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"sync_status": status})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	APIURL = srv.URL
+
+	err := td.Replay(nil)
+	var replayErr *ReplayError
+	if !errors.As(err, &replayErr) {
+		t.Fatalf("expected *ReplayError, got %v", err)
+	}
+	if len(replayErr.Rejected) != 1 || replayErr.Rejected[0].TaskID != "2" || len(replayErr.Errs) != 1 {
+		t.Errorf("expected the write to task 2 to be rejected, got %+v", replayErr)
+	}
+	if entries, _ := journal.Entries(); len(entries) != 0 {
+		t.Errorf("expected rejected entries to be discarded, got %d entries", len(entries))
+	}
+	if td.IsOffline() {
+		t.Error("expected client to be online after replay")
+	}
+}
+
+func TestOnlineWritesRefreshConflictBase(t *testing.T) {
+	var received []Command
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Task{ID: r.PathValue("id"), UpdatedAt: "2026-03-01T11:00:00Z"})
+	})
+	mux.HandleFunc("POST /sync", func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Commands []Command `json:"commands"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received = append(received, payload.Commands...)
+		status := map[string]string{}
+		for _, cmd := range payload.Commands {
+			status[cmd.UUID] = "ok"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"sync_status": status})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	td.EnableOffline(NewJournal(filepath.Join(t.TempDir(), "journal.jsonl")))
+	td.Tasks.Update([]Task{
+		{ID: "1", ProjectID: "100", SectionID: "s1", UpdatedAt: "2026-03-01T10:00:00Z"},
+		{ID: "2", ProjectID: "100", UpdatedAt: "2026-03-01T10:00:00Z"},
+	})
+
+	if err := td.Tasks.Get("1").MoveToRoot(); err != nil {
+		t.Fatalf("MoveToRoot() returned error: %v", err)
+	}
+	if report := td.Tasks.Bulk([]*Task{td.Tasks.Get("2")}).SetPriority(HIGH); report.Err() != nil {
+		t.Fatalf("SetPriority() returned error: %v", report.Err())
+	}
+	for _, id := range []string{"1", "2"} {
+		if got := td.Tasks.Get(id).UpdatedAt; got != "2026-03-01T11:00:00Z" {
+			t.Errorf("task %s: expected UpdatedAt from the server, got %q", id, got)
+		}
+	}
+
+	td.SetOffline(true)
+	if err := td.Tasks.Get("1").Close(); err != nil {
+		t.Fatalf("Close() while offline returned error: %v", err)
+	}
+	received = nil
+	err := td.Replay(func(c Conflict) Resolution {
+		t.Errorf("unexpected conflict on task %s", c.Remote.ID)
+		return KeepRemote
+	})
+	if err != nil {
+		t.Fatalf("Replay() returned error: %v", err)
+	}
+	if len(received) != 1 || received[0].Type != "item_close" {
+		t.Errorf("expected the offline write to be replayed, got %+v", received)
+	}
+}
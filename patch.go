@@ -0,0 +1,105 @@
+package godoist
+
+// TaskPatch collects changes to a task and sends them in a single request.
+type TaskPatch struct {
+	task   *Task
+	fields map[string]interface{}
+	local  []func(*Task)
+}
+
+// Patch starts a set of changes to the task, applied with Apply.
+func (t *Task) Patch() *TaskPatch {
+	return &TaskPatch{task: t, fields: make(map[string]interface{})}
+}
+
+// set records a field to send and how to apply it to the local task.
+func (p *TaskPatch) set(key string, value interface{}, local func(*Task)) *TaskPatch {
+	p.fields[key] = value
+	p.local = append(p.local, local)
+	return p
+}
+
+func (p *TaskPatch) Content(content string) *TaskPatch {
+	return p.set("content", content, func(t *Task) { t.Content = content })
+}
+
+// Description replaces the description. With DescriptionContextStore the
+// context blocks of the task are kept.
+func (p *TaskPatch) Description(description string) *TaskPatch {
+	if p.task.manager != nil {
+		if _, ok := p.task.manager.contextStore.(DescriptionContextStore); ok {
+			description = spliceContextBlocks(description, p.task.Description)
+		}
+	}
+	return p.rawDescription(description)
+}
+
+// rawDescription replaces the description as is, context blocks included.
+func (p *TaskPatch) rawDescription(description string) *TaskPatch {
+	return p.set("description", description, func(t *Task) { t.Description = description })
+}
+
+func (p *TaskPatch) Priority(priority PRIORITY_LEVEL) *TaskPatch {
+	return p.set("priority", priority, func(t *Task) { t.Priority = priority })
+}
+
+// Labels replaces all labels of the task.
+func (p *TaskPatch) Labels(labels ...string) *TaskPatch {
+	labels = append([]string{}, labels...)
+	return p.set("labels", labels, func(t *Task) { t.Labels = labels })
+}
+
+// DueString sets the due date from natural language, e.g. "tomorrow".
+func (p *TaskPatch) DueString(due string) *TaskPatch {
+	return p.set("due_string", due, func(t *Task) {
+		t.Due = &Due{String: due}
+	})
+}
+
+// Empty reports whether the patch has no changes.
+func (p *TaskPatch) Empty() bool {
+	return len(p.fields) == 0
+}
+
+// Apply sends all changes in one request and refreshes the task from the
+// server response. While offline the changes are journaled and applied to
+// the local task only.
+func (p *TaskPatch) Apply() error {
+	if p.Empty() {
+		return nil
+	}
+	t := p.task
+
+	args := syncArgs(p.fields)
+	args["id"] = t.ID
+
+	var updated *Task
+	err := t.manager.submit(t, func() error {
+		var err error
+		updated, err = t.manager.api.PatchTask(t.ID, p.fields)
+		return err
+	}, NewCommand("item_update", args))
+	if err != nil {
+		return err
+	}
+
+	if updated != nil && updated.ID == t.ID {
+		updated.manager = t.manager
+		*t = *updated
+	} else {
+		for _, apply := range p.local {
+			apply(t)
+		}
+	}
+	t.manager.Reindex(t)
+	return nil
+}
+
+// assign calls set with value if it has type T and reports whether it did.
+func assign[T any](value interface{}, set func(T) *TaskPatch) bool {
+	v, ok := value.(T)
+	if ok {
+		set(v)
+	}
+	return ok
+}
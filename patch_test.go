@@ -0,0 +1,71 @@
+package godoist
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTaskPatchApply(t *testing.T) {
+	var (
+		requests     int
+		receivedBody map[string]interface{}
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &receivedBody)
+		json.NewEncoder(w).Encode(Task{
+			ID:        r.PathValue("id"),
+			Content:   "Buy oat milk",
+			Priority:  HIGH,
+			Labels:    []string{"errands"},
+			Due:       &Due{Date: "2026-03-02", String: "tomorrow"},
+			UpdatedAt: "2026-03-01T10:00:00Z",
+		})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1", Content: "Buy milk"}})
+	task := td.Tasks.Get("1")
+
+	err := task.Patch().Content("Buy oat milk").Priority(HIGH).DueString("tomorrow").Labels("errands").Apply()
+	if err != nil {
+		t.Fatalf("Apply() returned error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single request, got %d", requests)
+	}
+	if receivedBody["content"] != "Buy oat milk" || receivedBody["priority"] != float64(HIGH) || receivedBody["due_string"] != "tomorrow" {
+		t.Errorf("unexpected request body: %v", receivedBody)
+	}
+	if task.UpdatedAt != "2026-03-01T10:00:00Z" || task.Due == nil || task.Due.ParsedDate.IsZero() {
+		t.Errorf("expected task to be refreshed from response, got %+v", task)
+	}
+	if td.Tasks.Get("1") != task {
+		t.Error("expected cached task pointer to be kept")
+	}
+}
+
+func TestTaskUpdateWrongType(t *testing.T) {
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{{ID: "1", Content: "Buy milk"}})
+
+	if err := td.Tasks.Get("1").Update("content", 42); err == nil {
+		t.Fatal("expected error for wrong value type")
+	}
+	if td.Tasks.Get("1").Content != "Buy milk" {
+		t.Error("expected task to be unchanged")
+	}
+}
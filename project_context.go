@@ -0,0 +1,68 @@
+package godoist
+
+// projectOwnerPrefix keeps project owner IDs apart from task IDs.
+const projectOwnerPrefix = "project:"
+
+// projectOwner attaches contexts to project comments.
+type projectOwner struct{ project *Project }
+
+func (o projectOwner) ownerID() string { return projectOwnerPrefix + o.project.ID }
+
+func (o projectOwner) comments() ([]Comment, error) {
+	return o.project.Manager.api.GetProjectComments(o.project.ID)
+}
+
+func (o projectOwner) createComment(content string) (*Comment, error) {
+	return o.project.Manager.api.CreateProjectComment(o.project.ID, content)
+}
+
+func (o projectOwner) updateComment(comment Comment, content string) error {
+	return o.project.Manager.api.UpdateComment(comment.ID, content)
+}
+
+func (o projectOwner) deleteComment(comment Comment) error {
+	return o.project.Manager.api.DeleteComment(comment.ID)
+}
+
+func (o projectOwner) preloaded() map[string]cachedContext { return nil }
+func (o projectOwner) invalidate()                         {}
+
+// Context returns the context of the project in namespace, stored in a
+// project comment. Validators and encryption configured for task contexts
+// apply as well.
+func (p *Project) Context(namespace string) *Context {
+	return &Context{manager: p.Manager.Manager.Tasks, owner: projectOwner{p}, namespace: namespace}
+}
+
+// Namespaces returns the namespaces of the contexts stored on the project
+// in alphabetical order. The default context is listed as the empty string.
+func (p *Project) Namespaces() ([]string, error) {
+	return contextNamespaces(projectOwner{p})
+}
+
+// GetContext retrieves the context data for a project
+func (p *Project) GetContext() (map[string]interface{}, error) {
+	return p.Context("").Get()
+}
+
+// SetContext sets or updates the context data for a project
+func (p *Project) SetContext(contextData map[string]interface{}) error {
+	return p.Context("").Set(contextData)
+}
+
+// UpdateContext updates specific fields in the context of a project
+// without replacing everything.
+func (p *Project) UpdateContext(updates map[string]interface{}) error {
+	return p.Context("").Update(updates)
+}
+
+// DeleteContext removes the context comment of a project entirely
+func (p *Project) DeleteContext() error {
+	return p.Context("").Delete()
+}
+
+// DeleteContextField removes a specific field from the context of a
+// project, and the whole context comment if no other field is left.
+func (p *Project) DeleteContextField(key string) error {
+	return p.Context("").DeleteField(key)
+}
@@ -0,0 +1,196 @@
+package godoist
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PathSeparator separates project names in a project path.
+const PathSeparator = "/"
+
+// ProjectTree is a snapshot of the project hierarchy of a ProjectManager.
+type ProjectTree struct {
+	roots    []*Project
+	children map[string][]*Project
+	// Orphans are projects whose parent is not in the cache. They are
+	// traversed like roots.
+	Orphans []*Project
+}
+
+// sortProjects orders projects by child order, falling back to the ID to
+// keep the order stable.
+func sortProjects(projects []*Project) {
+	sort.SliceStable(projects, func(i, j int) bool {
+		if projects[i].ChildOrder != projects[j].ChildOrder {
+			return projects[i].ChildOrder < projects[j].ChildOrder
+		}
+		return projects[i].ID < projects[j].ID
+	})
+}
+
+// Tree builds the project hierarchy from the cached projects.
+func (p *ProjectManager) Tree() *ProjectTree {
+	tree := &ProjectTree{children: make(map[string][]*Project)}
+	for _, project := range p.projects {
+		if project.ParentID == "" {
+			tree.roots = append(tree.roots, project)
+		} else if _, exists := p.projects[project.ParentID]; !exists {
+			tree.Orphans = append(tree.Orphans, project)
+		} else {
+			tree.children[project.ParentID] = append(tree.children[project.ParentID], project)
+		}
+	}
+
+	sortProjects(tree.roots)
+	sortProjects(tree.Orphans)
+	for _, children := range tree.children {
+		sortProjects(children)
+	}
+	return tree
+}
+
+// Roots returns the top-level projects ordered by child order.
+func (tree *ProjectTree) Roots() []*Project {
+	return tree.roots
+}
+
+// Children returns the direct subprojects of project ordered by child order.
+func (tree *ProjectTree) Children(project *Project) []*Project {
+	return tree.children[project.ID]
+}
+
+// Walk visits all projects in pre-order, roots first and orphans after
+// them. Returning false from fn skips the subprojects of the visited project.
+func (tree *ProjectTree) Walk(fn func(project *Project, depth int) bool) {
+	seen := make(map[string]bool)
+	var visit func(projects []*Project, depth int)
+	visit = func(projects []*Project, depth int) {
+		for _, project := range projects {
+			if seen[project.ID] {
+				continue
+			}
+			seen[project.ID] = true
+			if fn(project, depth) {
+				visit(tree.children[project.ID], depth+1)
+			}
+		}
+	}
+	visit(tree.roots, 0)
+	visit(tree.Orphans, 0)
+}
+
+// Parent returns the parent project, or nil for top-level projects and
+// projects whose parent is not in the cache.
+func (p *Project) Parent() *Project {
+	if p.ParentID == "" || p.Manager == nil {
+		return nil
+	}
+	return p.Manager.Get(p.ParentID)
+}
+
+// Path returns the names of the project and its parents joined by
+// PathSeparator, e.g. "Work/Clients/Acme".
+func (p *Project) Path() string {
+	names := []string{p.Name}
+	seen := map[string]bool{p.ID: true}
+	for parent := p.Parent(); parent != nil && !seen[parent.ID]; parent = parent.Parent() {
+		seen[parent.ID] = true
+		names = append(names, parent.Name)
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, PathSeparator)
+}
+
+// splitPath splits a project path into names, ignoring empty segments.
+func splitPath(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, PathSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// child returns the project named name below parentID ("" for the top
+// level), or nil if there is none.
+func (p *ProjectManager) child(parentID, name string) (*Project, error) {
+	var found *Project
+	for _, project := range p.projects {
+		if project.ParentID != parentID || project.Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("ambiguous project name %q", name)
+		}
+		found = project
+	}
+	return found, nil
+}
+
+// ResolvePath returns the project at a path such as "Work/Clients/Acme".
+func (p *ProjectManager) ResolvePath(path string) (*Project, error) {
+	names := splitPath(path)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("empty project path")
+	}
+
+	var project *Project
+	parentID := ""
+	for i, name := range names {
+		var err error
+		project, err = p.child(parentID, name)
+		if err != nil {
+			return nil, err
+		}
+		if project == nil {
+			return nil, fmt.Errorf("project not found: %s", strings.Join(names[:i+1], PathSeparator))
+		}
+		parentID = project.ID
+	}
+	return project, nil
+}
+
+// Create creates a project below parentID, or at the top level if parentID
+// is empty.
+func (p *ProjectManager) Create(name, parentID string) (*Project, error) {
+	fields := map[string]interface{}{"name": name}
+	if parentID != "" {
+		fields["parent_id"] = parentID
+	}
+	created, err := p.api.CreateProject(fields)
+	if err != nil {
+		return nil, err
+	}
+	p.AddProject(*created)
+	return p.Get(created.ID), nil
+}
+
+// EnsurePath resolves a project path, creating missing projects along the
+// way.
+func (p *ProjectManager) EnsurePath(path string) (*Project, error) {
+	names := splitPath(path)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("empty project path")
+	}
+
+	var project *Project
+	parentID := ""
+	for _, name := range names {
+		var err error
+		project, err = p.child(parentID, name)
+		if err != nil {
+			return nil, err
+		}
+		if project == nil {
+			if project, err = p.Create(name, parentID); err != nil {
+				return nil, err
+			}
+		}
+		parentID = project.ID
+	}
+	return project, nil
+}
@@ -0,0 +1,80 @@
+package godoist
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestProjectPaths(t *testing.T) {
+	var created []map[string]interface{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /projects", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var fields map[string]interface{}
+		json.Unmarshal(body, &fields)
+		created = append(created, fields)
+		parentID, _ := fields["parent_id"].(string)
+		json.NewEncoder(w).Encode(Project{
+			ID:       "new-" + strconv.Itoa(len(created)),
+			Name:     fields["name"].(string),
+			ParentID: parentID,
+		})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	td.Projects.Update([]Project{
+		{ID: "1", Name: "Work", ChildOrder: 2},
+		{ID: "2", Name: "Home", ChildOrder: 1},
+		{ID: "3", Name: "Clients", ParentID: "1", ChildOrder: 2},
+		{ID: "4", Name: "Internal", ParentID: "1", ChildOrder: 1},
+	})
+
+	clients, err := td.Projects.ResolvePath("Work/Clients")
+	if err != nil || clients.ID != "3" {
+		t.Fatalf("expected Work/Clients to resolve to 3, got %v (%v)", clients, err)
+	}
+	if clients.Path() != "Work/Clients" || clients.Parent().ID != "1" {
+		t.Errorf("unexpected path %q", clients.Path())
+	}
+	if _, err := td.Projects.ResolvePath("Work/Clients/Acme"); err == nil {
+		t.Error("expected error for missing project")
+	}
+
+	acme, err := td.Projects.EnsurePath("Work/Clients/Acme/Website")
+	if err != nil {
+		t.Fatalf("EnsurePath() returned error: %v", err)
+	}
+	if len(created) != 2 || created[0]["parent_id"] != "3" || created[1]["parent_id"] != "new-1" {
+		t.Errorf("expected Acme and Website to be created, got %v", created)
+	}
+	if acme.Path() != "Work/Clients/Acme/Website" {
+		t.Errorf("unexpected path %q", acme.Path())
+	}
+
+	var order []string
+	td.Projects.Tree().Walk(func(project *Project, depth int) bool {
+		order = append(order, project.ID)
+		return true
+	})
+	expected := []string{"2", "1", "4", "3", "new-1", "new-2"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, order)
+		}
+	}
+}
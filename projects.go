@@ -13,6 +13,7 @@ type Project struct {
 	InboxProject bool            `json:"inbox_project"`
 	IsFavorite   bool            `json:"is_favorite"`
 	IsArchived   bool            `json:"is_archived"`
+	IsDeleted    bool            `json:"is_deleted"`
 	IsCollapsed  bool            `json:"is_collapsed"`
 	ViewStyle    string          `json:"view_style"`
 	DefaultOrder int             `json:"default_order"`
@@ -45,25 +46,21 @@ func (p *Project) GetTasks() []*Task {
 		return nil
 	}
 
-	tasks := []*Task{}
-	for _, task := range p.Manager.Manager.Tasks.All() {
-		if task.ProjectID == p.ID {
-			tasks = append(tasks, task)
-		}
-	}
-	return tasks
+	return p.Manager.Manager.Tasks.ByProject(p.ID)
 }
 
+// GetChildren returns the direct subprojects ordered by child order.
 func (p *Project) GetChildren() []*Project {
 	if p.Manager == nil {
 		return nil
 	}
 
 	projects := []*Project{}
-	for _, project := range p.Manager.All() {
+	for _, project := range p.Manager.projects {
 		if project.ParentID == p.ID {
 			projects = append(projects, project)
 		}
 	}
+	sortProjects(projects)
 	return projects
 }
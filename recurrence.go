@@ -0,0 +1,495 @@
+package godoist
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RecurrenceUnit int
+
+const (
+	Hourly RecurrenceUnit = iota
+	Daily
+	Weekly
+	Monthly
+	Yearly
+)
+
+// Recurrence is a parsed recurring due date such as "every 2 weeks".
+type Recurrence struct {
+	Unit     RecurrenceUnit
+	Interval int
+	// Weekdays restricts daily and weekly recurrences to these days.
+	Weekdays []time.Weekday
+	// MonthDay is the day of the month of monthly and yearly recurrences,
+	// clamped to the last day of shorter months. Zero uses the anchor date.
+	MonthDay int
+	// Month is the month of yearly recurrences. Zero uses the anchor date.
+	Month time.Month
+	// NthWeekday selects the nth Weekdays[0] of the month for monthly
+	// recurrences, -1 meaning the last one.
+	NthWeekday int
+	HasTime    bool
+	Hour       int
+	Minute     int
+	// FromCompletion is set for "every!" patterns, which repeat from the
+	// completion date. Occurrences assume completion on the due date.
+	FromCompletion bool
+	// Start and End bound the occurrences. A zero year means the bound
+	// was given without one and refers to the year of the anchor date.
+	Start time.Time
+	End   time.Time
+}
+
+// UnsupportedRecurrenceError is returned for recurrence patterns that the
+// local engine does not understand.
+type UnsupportedRecurrenceError struct {
+	Pattern string
+	Reason  string
+}
+
+func (e *UnsupportedRecurrenceError) Error() string {
+	return fmt.Sprintf("unsupported recurrence %q: %s", e.Pattern, e.Reason)
+}
+
+var (
+	weekdayNames = map[string]time.Weekday{
+		"sun": time.Sunday, "sunday": time.Sunday,
+		"mon": time.Monday, "monday": time.Monday,
+		"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+		"wed": time.Wednesday, "wednesday": time.Wednesday,
+		"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+		"fri": time.Friday, "friday": time.Friday,
+		"sat": time.Saturday, "saturday": time.Saturday,
+	}
+	monthNames = map[string]time.Month{
+		"jan": time.January, "january": time.January,
+		"feb": time.February, "february": time.February,
+		"mar": time.March, "march": time.March,
+		"apr": time.April, "april": time.April,
+		"may": time.May,
+		"jun": time.June, "june": time.June,
+		"jul": time.July, "july": time.July,
+		"aug": time.August, "august": time.August,
+		"sep": time.September, "sept": time.September, "september": time.September,
+		"oct": time.October, "october": time.October,
+		"nov": time.November, "november": time.November,
+		"dec": time.December, "december": time.December,
+	}
+	unitNames = map[string]RecurrenceUnit{
+		"hour": Hourly, "hours": Hourly,
+		"day": Daily, "days": Daily,
+		"week": Weekly, "weeks": Weekly,
+		"month": Monthly, "months": Monthly,
+		"year": Yearly, "years": Yearly,
+	}
+	adverbUnits = map[string]RecurrenceUnit{
+		"hourly": Hourly, "daily": Daily, "weekly": Weekly,
+		"monthly": Monthly, "yearly": Yearly, "annually": Yearly,
+	}
+	// dayParts are the times Todoist assigns to "every morning" and friends.
+	dayParts = map[string][2]int{
+		"morning": {9, 0}, "afternoon": {12, 0}, "evening": {19, 0}, "night": {22, 0},
+	}
+
+	timePattern    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	ordinalPattern = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)$`)
+)
+
+// ParseRecurrence parses the common recurrence grammar of Todoist due
+// strings, e.g. "every day", "every 2 weeks", "every mon, fri at 9am",
+// "every 3rd friday", "every! 5 days" or "ev workday starting jan 5".
+func ParseRecurrence(pattern string) (*Recurrence, error) {
+	s := strings.Join(strings.Fields(strings.ToLower(pattern)), " ")
+	unsupported := func(reason string) error {
+		return &UnsupportedRecurrenceError{Pattern: pattern, Reason: reason}
+	}
+	r := &Recurrence{Interval: 1}
+
+	var err error
+	if s, r.End, err = cutBound(s, " ending ", " until "); err != nil {
+		return nil, unsupported(err.Error())
+	}
+	if s, r.Start, err = cutBound(s, " starting ", " from "); err != nil {
+		return nil, unsupported(err.Error())
+	}
+
+	if before, after, found := strings.Cut(s, " at "); found {
+		if !r.setTime(after, true) {
+			return nil, unsupported("invalid time " + after)
+		}
+		s = before
+	} else if i := strings.LastIndex(s, " "); i > 0 && r.setTime(s[i+1:], false) {
+		s = s[:i]
+	}
+
+	head, body, _ := strings.Cut(s, " ")
+	switch head {
+	case "every", "ev":
+	case "every!", "ev!":
+		r.FromCompletion = true
+	default:
+		if unit, ok := adverbUnits[head]; ok && body == "" {
+			r.Unit = unit
+			return r, nil
+		}
+		return nil, unsupported("expected \"every\"")
+	}
+	if body == "" {
+		return nil, unsupported("missing interval")
+	}
+	if err := r.parseBody(body); err != nil {
+		return nil, unsupported(err.Error())
+	}
+	return r, nil
+}
+
+// parseBody parses what follows "every".
+func (r *Recurrence) parseBody(body string) error {
+	words := strings.Fields(body)
+
+	switch {
+	case body == "workday" || body == "weekday" || body == "work day":
+		r.Unit = Daily
+		r.Weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+		return nil
+	case body == "weekend":
+		r.Unit = Daily
+		r.Weekdays = []time.Weekday{time.Saturday, time.Sunday}
+		return nil
+	}
+	if part, ok := dayParts[body]; ok {
+		r.Unit = Daily
+		if !r.HasTime {
+			r.HasTime, r.Hour, r.Minute = true, part[0], part[1]
+		}
+		return nil
+	}
+
+	// "every 2 weeks", "every other day", "every week"
+	if len(words) <= 2 {
+		interval, unitWord := 1, words[len(words)-1]
+		if len(words) == 2 {
+			if words[0] == "other" {
+				interval = 2
+			} else if n, err := strconv.Atoi(words[0]); err == nil && n > 0 {
+				interval = n
+			} else {
+				interval = 0
+			}
+		}
+		if unit, ok := unitNames[unitWord]; ok && interval > 0 {
+			r.Unit, r.Interval = unit, interval
+			return nil
+		}
+	}
+
+	// "every 3rd friday", "every last fri"
+	if len(words) == 2 {
+		if weekday, ok := weekdayNames[words[1]]; ok {
+			nth := 0
+			if words[0] == "last" {
+				nth = -1
+			} else if m := ordinalPattern.FindStringSubmatch(words[0]); m != nil {
+				nth, _ = strconv.Atoi(m[1])
+			}
+			if nth == -1 || (nth >= 1 && nth <= 5) {
+				r.Unit, r.NthWeekday, r.Weekdays = Monthly, nth, []time.Weekday{weekday}
+				return nil
+			}
+		}
+	}
+
+	// "every 15th"
+	if len(words) == 1 {
+		if m := ordinalPattern.FindStringSubmatch(words[0]); m != nil {
+			day, _ := strconv.Atoi(m[1])
+			if day < 1 || day > 31 {
+				return fmt.Errorf("invalid day of month %d", day)
+			}
+			r.Unit, r.MonthDay = Monthly, day
+			return nil
+		}
+	}
+
+	// "every jan 15", "every 15 jan"
+	if month, day, ok := parseMonthDay(words); ok {
+		r.Unit, r.Month, r.MonthDay = Yearly, month, day
+		return nil
+	}
+
+	// "every mon, fri", "every other tuesday", "every monday and thursday"
+	if words[0] == "other" {
+		r.Interval = 2
+		body = strings.TrimPrefix(body, "other ")
+	}
+	for _, name := range strings.FieldsFunc(strings.ReplaceAll(body, " and ", ","), func(c rune) bool { return c == ',' }) {
+		weekday, ok := weekdayNames[strings.TrimSpace(name)]
+		if !ok {
+			return fmt.Errorf("unknown interval %q", body)
+		}
+		r.Weekdays = append(r.Weekdays, weekday)
+	}
+	r.Unit = Weekly
+	return nil
+}
+
+// setTime parses a time of day such as "9am", "9:30" or "17:00". A bare
+// hour like "9" is only accepted after "at".
+func (r *Recurrence) setTime(s string, bare bool) bool {
+	m := timePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || (!bare && m[2] == "" && m[3] == "") {
+		return false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	switch m[3] {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return false
+	}
+	r.HasTime, r.Hour, r.Minute = true, hour, minute
+	return true
+}
+
+// cutBound removes a "starting <date>" style clause from s.
+func cutBound(s string, keywords ...string) (string, time.Time, error) {
+	for _, keyword := range keywords {
+		i := strings.Index(s, keyword)
+		if i < 0 {
+			continue
+		}
+		rest := s[i+len(keyword):]
+		end := len(rest)
+		for _, other := range []string{" ending ", " until ", " starting ", " from ", " at "} {
+			if j := strings.Index(rest, other); j >= 0 && j < end {
+				end = j
+			}
+		}
+		date, err := parseBoundDate(rest[:end])
+		if err != nil {
+			return s, time.Time{}, err
+		}
+		return s[:i] + rest[end:], date, nil
+	}
+	return s, time.Time{}, nil
+}
+
+// parseBoundDate parses "2026-01-05", "jan 5", "5 jan" or "jan 5 2026".
+func parseBoundDate(s string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, s); err == nil {
+		return date, nil
+	}
+	words := strings.Fields(strings.ReplaceAll(s, ",", " "))
+	year := 0
+	if len(words) == 3 {
+		y, err := strconv.Atoi(words[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", s)
+		}
+		year, words = y, words[:2]
+	}
+	month, day, ok := parseMonthDay(words)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), nil
+}
+
+// parseMonthDay parses ["jan", "15"] or ["15th", "jan"].
+func parseMonthDay(words []string) (time.Month, int, bool) {
+	if len(words) != 2 {
+		return 0, 0, false
+	}
+	monthWord, dayWord := words[0], words[1]
+	if _, ok := monthNames[monthWord]; !ok {
+		monthWord, dayWord = dayWord, monthWord
+	}
+	month, ok := monthNames[monthWord]
+	if !ok {
+		return 0, 0, false
+	}
+	if m := ordinalPattern.FindStringSubmatch(dayWord); m != nil {
+		dayWord = m[1]
+	}
+	day, err := strconv.Atoi(dayWord)
+	if err != nil || day < 1 || day > 31 {
+		return 0, 0, false
+	}
+	return month, day, true
+}
+
+// maxRecurrenceDays bounds the search for occurrences of patterns that
+// rarely match, such as "every feb 29".
+const maxRecurrenceDays = 400 * 366
+
+// Next returns the first n occurrences after from. The anchor is the date
+// the recurrence counts intervals from, usually the current due date.
+func (r *Recurrence) Next(anchor, from time.Time, n int) []time.Time {
+	if n <= 0 {
+		return nil
+	}
+	loc := anchor.Location()
+	start := resolveBound(r.Start, anchor, loc)
+	// end is exclusive, so that occurrences on the last day are included.
+	end := resolveBound(r.End, anchor, loc)
+	if !end.IsZero() {
+		end = end.AddDate(0, 0, 1)
+	}
+	hour, minute := anchor.Hour(), anchor.Minute()
+	if !start.IsZero() {
+		anchor = time.Date(start.Year(), start.Month(), start.Day(), hour, minute, 0, 0, loc)
+	}
+	if r.HasTime {
+		hour, minute = r.Hour, r.Minute
+	}
+
+	var occurrences []time.Time
+	accept := func(at time.Time) bool {
+		if !at.After(from) || (!start.IsZero() && at.Before(start)) {
+			return true
+		}
+		if !end.IsZero() && !at.Before(end) {
+			return false
+		}
+		occurrences = append(occurrences, at)
+		return len(occurrences) < n
+	}
+
+	if r.Unit == Hourly {
+		step := time.Duration(r.Interval) * time.Hour
+		at := anchor
+		if from.After(at) {
+			at = at.Add(from.Sub(at) / step * step)
+		}
+		for accept(at) {
+			at = at.Add(step)
+		}
+		return occurrences
+	}
+
+	anchorDay := time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, loc)
+	day := anchorDay
+	if from.After(day) {
+		day = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	}
+	for i := 0; i < maxRecurrenceDays; i++ {
+		if r.matches(anchorDay, day) {
+			at := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+			if !accept(at) {
+				break
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return occurrences
+}
+
+// matches reports whether day is an occurrence of a day based recurrence.
+func (r *Recurrence) matches(anchor, day time.Time) bool {
+	days := int(day.Sub(anchor).Hours()+12) / 24
+	months := (day.Year()-anchor.Year())*12 + int(day.Month()-anchor.Month())
+
+	switch r.Unit {
+	case Daily:
+		return days%r.Interval == 0 && (len(r.Weekdays) == 0 || containsWeekday(r.Weekdays, day.Weekday()))
+	case Weekly:
+		weekdays := r.Weekdays
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{anchor.Weekday()}
+		}
+		weeks := (days + int(anchor.Weekday()+6)%7) / 7
+		return weeks%r.Interval == 0 && containsWeekday(weekdays, day.Weekday())
+	case Monthly:
+		if months%r.Interval != 0 {
+			return false
+		}
+		if r.NthWeekday != 0 {
+			return day.Weekday() == r.Weekdays[0] && isNthWeekday(day, r.NthWeekday)
+		}
+		return day.Day() == clampDay(day, r.monthDay(anchor))
+	case Yearly:
+		month := r.Month
+		if month == 0 {
+			month = anchor.Month()
+		}
+		years := day.Year() - anchor.Year()
+		return years%r.Interval == 0 && day.Month() == month && day.Day() == clampDay(day, r.monthDay(anchor))
+	}
+	return false
+}
+
+func (r *Recurrence) monthDay(anchor time.Time) int {
+	if r.MonthDay != 0 {
+		return r.MonthDay
+	}
+	return anchor.Day()
+}
+
+// clampDay limits day to the number of days in the month of t.
+func clampDay(t time.Time, day int) int {
+	last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	return min(day, last)
+}
+
+func isNthWeekday(day time.Time, nth int) bool {
+	if nth == -1 {
+		return day.AddDate(0, 0, 7).Month() != day.Month()
+	}
+	return (day.Day()-1)/7+1 == nth
+}
+
+func containsWeekday(weekdays []time.Weekday, weekday time.Weekday) bool {
+	for _, w := range weekdays {
+		if w == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveBound moves a bound without a year into the year of anchor.
+func resolveBound(bound, anchor time.Time, loc *time.Location) time.Time {
+	if bound.IsZero() {
+		return bound
+	}
+	year := bound.Year()
+	if year == 0 {
+		year = anchor.Year()
+	}
+	return time.Date(year, bound.Month(), bound.Day(), 0, 0, 0, 0, loc)
+}
+
+// Recurrence parses the recurrence of a recurring due date.
+func (d *Due) Recurrence() (*Recurrence, error) {
+	if !d.IsRecurring {
+		return nil, &UnsupportedRecurrenceError{Pattern: d.String, Reason: "due date is not recurring"}
+	}
+	return ParseRecurrence(d.String)
+}
+
+// NextOccurrences predicts the next n occurrences of a recurring due date
+// after from. Patterns the local engine cannot handle are reported as an
+// *UnsupportedRecurrenceError.
+func (d *Due) NextOccurrences(from time.Time, n int) ([]time.Time, error) {
+	r, err := d.Recurrence()
+	if err != nil {
+		return nil, err
+	}
+	anchor := d.Local()
+	if anchor.IsZero() {
+		anchor = from
+	}
+	return r.Next(anchor, from, n), nil
+}
@@ -0,0 +1,71 @@
+package godoist
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNextOccurrences(t *testing.T) {
+	// Monday, 2 March 2026
+	from := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	date := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		pattern  string
+		anchor   time.Time
+		expected []time.Time
+	}{
+		{"every day", date(3, 2, 0, 0), []time.Time{date(3, 3, 0, 0), date(3, 4, 0, 0), date(3, 5, 0, 0)}},
+		{"daily at 9am", date(3, 2, 0, 0), []time.Time{date(3, 2, 9, 0), date(3, 3, 9, 0), date(3, 4, 9, 0)}},
+		{"every 2 weeks", date(2, 23, 0, 0), []time.Time{date(3, 9, 0, 0), date(3, 23, 0, 0), date(4, 6, 0, 0)}},
+		{"every mon, fri at 9am", date(3, 2, 9, 0), []time.Time{date(3, 2, 9, 0), date(3, 6, 9, 0), date(3, 9, 9, 0)}},
+		{"every monday and friday 5:30pm", date(3, 2, 0, 0), []time.Time{date(3, 2, 17, 30), date(3, 6, 17, 30), date(3, 9, 17, 30)}},
+		{"every 3rd friday", date(3, 2, 0, 0), []time.Time{date(3, 20, 0, 0), date(4, 17, 0, 0), date(5, 15, 0, 0)}},
+		{"every last fri", date(3, 2, 0, 0), []time.Time{date(3, 27, 0, 0), date(4, 24, 0, 0), date(5, 29, 0, 0)}},
+		{"every! 5 days", date(3, 1, 0, 0), []time.Time{date(3, 6, 0, 0), date(3, 11, 0, 0), date(3, 16, 0, 0)}},
+		{"ev workday", date(3, 6, 0, 0), []time.Time{date(3, 6, 0, 0), date(3, 9, 0, 0), date(3, 10, 0, 0)}},
+		{"every 31st", date(3, 31, 0, 0), []time.Time{date(3, 31, 0, 0), date(4, 30, 0, 0), date(5, 31, 0, 0)}},
+		{"every other tuesday", date(3, 3, 0, 0), []time.Time{date(3, 3, 0, 0), date(3, 17, 0, 0), date(3, 31, 0, 0)}},
+		{"every day starting mar 10 ending mar 11", date(3, 2, 0, 0), []time.Time{date(3, 10, 0, 0), date(3, 11, 0, 0)}},
+		{"every day starting mar 10", date(3, 2, 9, 0), []time.Time{date(3, 10, 9, 0), date(3, 11, 9, 0), date(3, 12, 9, 0)}},
+		{"every day until mar 4", date(3, 2, 9, 0), []time.Time{date(3, 2, 9, 0), date(3, 3, 9, 0), date(3, 4, 9, 0)}},
+		{"every 12 hours until mar 3", date(3, 2, 9, 0), []time.Time{date(3, 2, 9, 0), date(3, 2, 21, 0), date(3, 3, 9, 0)}},
+		{"every 6 hours", date(3, 2, 0, 0), []time.Time{date(3, 2, 12, 0), date(3, 2, 18, 0), date(3, 3, 0, 0)}},
+		{"every jan 15", date(1, 15, 0, 0), []time.Time{date(1, 15, 0, 0).AddDate(1, 0, 0), date(1, 15, 0, 0).AddDate(2, 0, 0), date(1, 15, 0, 0).AddDate(3, 0, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			due := &Due{String: tt.pattern, IsRecurring: true, ParsedDate: tt.anchor}
+			got, err := due.NextOccurrences(from, 3)
+			if err != nil {
+				t.Fatalf("NextOccurrences() returned error: %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.expected[i]) {
+					t.Errorf("occurrence %d: expected %v, got %v", i, tt.expected[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceUnsupported(t *testing.T) {
+	for _, pattern := range []string{"every", "tomorrow", "every 2nd blursday", "every day at 25:00"} {
+		_, err := ParseRecurrence(pattern)
+		var unsupported *UnsupportedRecurrenceError
+		if !errors.As(err, &unsupported) {
+			t.Errorf("%q: expected UnsupportedRecurrenceError, got %v", pattern, err)
+		}
+	}
+
+	due := &Due{String: "tomorrow", IsRecurring: false}
+	if _, err := due.NextOccurrences(time.Now(), 1); err == nil {
+		t.Error("expected error for non-recurring due date")
+	}
+}
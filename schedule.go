@@ -0,0 +1,113 @@
+package godoist
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const oneDay = 24 * time.Hour
+
+// ToStd converts the duration to a time.Duration.
+func (d *Duration) ToStd() time.Duration {
+	switch d.Unit {
+	case "day":
+		return time.Duration(d.Amount) * oneDay
+	default:
+		return time.Duration(d.Amount) * time.Minute
+	}
+}
+
+// durationFromStd converts a time.Duration to whole days if possible and to
+// minutes otherwise.
+func durationFromStd(d time.Duration) (*Duration, error) {
+	if d < time.Minute {
+		return nil, fmt.Errorf("duration must be at least one minute, got %s", d)
+	}
+	if d%oneDay == 0 {
+		return &Duration{Amount: int(d / oneDay), Unit: "day"}, nil
+	}
+	return &Duration{Amount: int(d.Round(time.Minute) / time.Minute), Unit: "minute"}, nil
+}
+
+// Duration sets how long the task takes.
+func (p *TaskPatch) Duration(duration *Duration) *TaskPatch {
+	if duration == nil {
+		return p.ClearDuration()
+	}
+	value := *duration
+	p.set("duration", value.Amount, func(t *Task) { t.Duration = &value })
+	return p.set("duration_unit", value.Unit, func(*Task) {})
+}
+
+// ClearDuration removes the duration.
+func (p *TaskPatch) ClearDuration() *TaskPatch {
+	p.set("duration", nil, func(t *Task) { t.Duration = nil })
+	return p.set("duration_unit", nil, func(*Task) {})
+}
+
+// SetDuration sets how long the task takes. Whole days are stored as days,
+// anything else is rounded to minutes.
+func (t *Task) SetDuration(d time.Duration) error {
+	duration, err := durationFromStd(d)
+	if err != nil {
+		return err
+	}
+	return t.Patch().Duration(duration).Apply()
+}
+
+// TimeBlock is a timed task laid out on a day.
+type TimeBlock struct {
+	Task  *Task
+	Start time.Time
+	// End equals Start for tasks without a duration.
+	End time.Time
+	// Overlaps lists the other tasks scheduled during this block.
+	Overlaps []*Task
+}
+
+func (b *TimeBlock) overlaps(other *TimeBlock) bool {
+	if b.Start.Equal(other.Start) {
+		return true
+	}
+	return b.Start.Before(other.End) && other.Start.Before(b.End)
+}
+
+// Schedule lays out the tasks due at a time of day on the given day,
+// ordered by start time, and detects overlapping time blocks.
+func (t *TaskManager) Schedule(date time.Time) []TimeBlock {
+	loc := date.Location()
+	year, month, dayOfMonth := date.Date()
+
+	blocks := make([]TimeBlock, 0)
+	for _, task := range t.tasks {
+		if task.Due == nil || !task.Due.HasTime() || task.Due.ParsedDate.IsZero() {
+			continue
+		}
+		start := task.Due.Local().In(loc)
+		if y, m, d := start.Date(); y != year || m != month || d != dayOfMonth {
+			continue
+		}
+		block := TimeBlock{Task: task, Start: start, End: start}
+		if task.Duration != nil {
+			block.End = start.Add(task.Duration.ToStd())
+		}
+		blocks = append(blocks, block)
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].Start.Equal(blocks[j].Start) {
+			return blocks[i].Task.ID < blocks[j].Task.ID
+		}
+		return blocks[i].Start.Before(blocks[j].Start)
+	})
+	for i := range blocks {
+		for j := i + 1; j < len(blocks) && !blocks[j].Start.After(blocks[i].End); j++ {
+			if blocks[i].overlaps(&blocks[j]) {
+				blocks[i].Overlaps = append(blocks[i].Overlaps, blocks[j].Task)
+				blocks[j].Overlaps = append(blocks[j].Overlaps, blocks[i].Task)
+			}
+		}
+	}
+	return blocks
+}
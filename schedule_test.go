@@ -0,0 +1,62 @@
+package godoist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationConversion(t *testing.T) {
+	if got := (&Duration{Amount: 90, Unit: "minute"}).ToStd(); got != 90*time.Minute {
+		t.Errorf("expected 90m, got %s", got)
+	}
+	if got := (&Duration{Amount: 2, Unit: "day"}).ToStd(); got != 48*time.Hour {
+		t.Errorf("expected 48h, got %s", got)
+	}
+
+	d, err := durationFromStd(48 * time.Hour)
+	if err != nil || d.Amount != 2 || d.Unit != "day" {
+		t.Errorf("expected 2 days, got %+v (%v)", d, err)
+	}
+	d, err = durationFromStd(25 * time.Hour)
+	if err != nil || d.Amount != 1500 || d.Unit != "minute" {
+		t.Errorf("expected 1500 minutes, got %+v (%v)", d, err)
+	}
+	if _, err := durationFromStd(30 * time.Second); err == nil {
+		t.Error("expected error for sub-minute duration")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	at := func(hour, minute int) *Due {
+		parsed := time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC)
+		return &Due{Date: parsed.Format(time.RFC3339), ParsedDate: parsed}
+	}
+	minutes := func(n int) *Duration { return &Duration{Amount: n, Unit: "minute"} }
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{
+		{ID: "standup", Due: at(9, 0), Duration: minutes(15)},
+		{ID: "review", Due: at(9, 10), Duration: minutes(30)},
+		{ID: "lunch", Due: at(12, 0), Duration: minutes(60)},
+		{ID: "call", Due: at(13, 0)},
+		{ID: "allday", Due: &Due{Date: "2026-03-02", ParsedDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)}},
+		{ID: "tomorrow", Due: at(33, 0)},
+	})
+
+	blocks := td.Tasks.Schedule(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC))
+	if len(blocks) != 4 {
+		t.Fatalf("expected 4 timed blocks, got %d", len(blocks))
+	}
+	expected := []struct {
+		id       string
+		overlaps int
+	}{{"standup", 1}, {"review", 1}, {"lunch", 0}, {"call", 0}}
+	for i, e := range expected {
+		if blocks[i].Task.ID != e.id || len(blocks[i].Overlaps) != e.overlaps {
+			t.Errorf("block %d: expected %s with %d overlaps, got %s with %d", i, e.id, e.overlaps, blocks[i].Task.ID, len(blocks[i].Overlaps))
+		}
+	}
+	if !blocks[0].End.Equal(time.Date(2026, 3, 2, 9, 15, 0, 0, time.UTC)) {
+		t.Errorf("unexpected end of standup: %v", blocks[0].End)
+	}
+}
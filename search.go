@@ -0,0 +1,272 @@
+package godoist
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Weights of the fields a task is searched by.
+const (
+	contentWeight     = 3
+	labelWeight       = 3
+	descriptionWeight = 1
+	commentWeight     = 1
+)
+
+// Factors applied to the field weight depending on how a query token
+// matched an indexed token.
+const (
+	exactMatch     = 1.0
+	prefixMatch    = 0.75
+	substringMatch = 0.5
+	fuzzyMatch     = 0.4
+	// phraseBonus is added per field weight if the whole query occurs in a
+	// field as is.
+	phraseBonus = 2.0
+)
+
+// tokenize splits text into lower case words.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchIndex is an inverted index from words to the tasks containing them.
+type searchIndex struct {
+	// postings maps a token to the summed field weights per task ID.
+	postings map[string]map[string]int
+	// tokens remembers what was indexed per task ID for removal.
+	tokens map[string][]string
+	// sorted lists the indexed tokens in order for prefix lookups. It is
+	// reset when tokens are added or removed and rebuilt on demand.
+	sorted []string
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{postings: make(map[string]map[string]int), tokens: make(map[string][]string)}
+}
+
+// add indexes text for the task with the given ID.
+func (ix *searchIndex) add(id string, weight int, text string) {
+	for _, token := range tokenize(text) {
+		if ix.postings[token] == nil {
+			ix.postings[token] = make(map[string]int)
+			ix.sorted = nil
+		}
+		if ix.postings[token][id] == 0 {
+			ix.tokens[id] = append(ix.tokens[id], token)
+		}
+		ix.postings[token][id] += weight
+	}
+}
+
+func (ix *searchIndex) remove(id string) {
+	for _, token := range ix.tokens[id] {
+		delete(ix.postings[token], id)
+		if len(ix.postings[token]) == 0 {
+			delete(ix.postings, token)
+			ix.sorted = nil
+		}
+	}
+	delete(ix.tokens, id)
+}
+
+// match scores every task containing a token similar to query token q.
+// Exact and prefix matches are looked up directly. Substring and typo
+// matches need a scan of all tokens, so they are only tried if q matched
+// nothing else.
+func (ix *searchIndex) match(q string, scores map[string]float64) {
+	found := addScores(ix.postings[q], exactMatch, scores)
+	vocabulary := ix.vocabulary()
+	i, _ := slices.BinarySearch(vocabulary, q)
+	for _, token := range vocabulary[i:] {
+		if !strings.HasPrefix(token, q) {
+			break
+		}
+		if token != q {
+			found = addScores(ix.postings[token], prefixMatch, scores) || found
+		}
+	}
+	if found {
+		return
+	}
+
+	for token, postings := range ix.postings {
+		switch {
+		case strings.Contains(token, q):
+			addScores(postings, substringMatch, scores)
+		case withinEditDistance(q, token, maxEdits(q)):
+			addScores(postings, fuzzyMatch, scores)
+		}
+	}
+}
+
+// vocabulary returns the indexed tokens in sorted order.
+func (ix *searchIndex) vocabulary() []string {
+	if ix.sorted == nil {
+		ix.sorted = slices.Sorted(maps.Keys(ix.postings))
+	}
+	return ix.sorted
+}
+
+// addScores raises the score of every task in postings to its weight
+// times factor and reports whether there were any.
+func addScores(postings map[string]int, factor float64, scores map[string]float64) bool {
+	for id, weight := range postings {
+		scores[id] = max(scores[id], factor*float64(weight))
+	}
+	return len(postings) > 0
+}
+
+// maxEdits is the number of typos tolerated in a query token.
+func maxEdits(token string) int {
+	switch n := len([]rune(token)); {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// withinEditDistance reports whether the Levenshtein distance between a
+// and b is at most limit.
+func withinEditDistance(a, b string, limit int) bool {
+	if limit == 0 {
+		return false
+	}
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return false
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		best := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			best = min(best, curr[j])
+		}
+		if best > limit {
+			return false
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)] <= limit
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// indexTask adds the searchable fields of task to the index.
+func (ix *searchIndex) indexTask(task *Task) {
+	ix.add(task.ID, contentWeight, task.Content)
+	ix.add(task.ID, descriptionWeight, stripContextBlocks(task.Description))
+	for _, label := range task.Labels {
+		ix.add(task.ID, labelWeight, label)
+	}
+}
+
+// indexComments adds the text of comments to the index, skipping context
+// comments which hold machine readable data.
+func (ix *searchIndex) indexComments(id string, comments []Comment) {
+	ix.remove(id)
+	for _, comment := range comments {
+		if _, isContext := contextNamespace(comment.Content); !isContext {
+			ix.add(id, commentWeight, comment.Content)
+		}
+	}
+}
+
+// SearchResult is a task matching a search query.
+type SearchResult struct {
+	Task  *Task
+	Score float64
+}
+
+type SearchOptions struct {
+	// Comments also searches comment text. Only comments that have been
+	// fetched before, e.g. with Task.GetComments, are searched.
+	Comments bool
+	// Limit caps the number of results, 0 meaning no limit.
+	Limit int
+}
+
+// Search returns the tasks matching query by content, description and
+// labels, best matches first.
+func (t *TaskManager) Search(query string) []SearchResult {
+	return t.SearchWith(query, SearchOptions{})
+}
+
+// SearchWith returns the tasks matching query, best matches first. Every
+// word of the query has to match a word of the task exactly, as prefix,
+// or, if no task has such a word, as substring or with a typo.
+func (t *TaskManager) SearchWith(query string, opts SearchOptions) []SearchResult {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var totals map[string]float64
+	for _, term := range terms {
+		scores := make(map[string]float64)
+		t.indexes.search.match(term, scores)
+		if opts.Comments {
+			t.indexes.commentSearch.match(term, scores)
+		}
+
+		if totals == nil {
+			totals = scores
+			continue
+		}
+		for id := range totals {
+			if score, ok := scores[id]; ok {
+				totals[id] += score
+			} else {
+				delete(totals, id)
+			}
+		}
+	}
+
+	phrase := strings.ToLower(strings.TrimSpace(query))
+	results := make([]SearchResult, 0, len(totals))
+	for id, score := range totals {
+		task, exists := t.tasks[id]
+		if !exists {
+			continue
+		}
+		if strings.Contains(strings.ToLower(task.Content), phrase) {
+			score += phraseBonus * contentWeight
+		}
+		if strings.Contains(strings.ToLower(task.Description), phrase) {
+			score += phraseBonus * descriptionWeight
+		}
+		results = append(results, SearchResult{Task: task, Score: score})
+	}
+
+	slices.SortFunc(results, func(a, b SearchResult) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Task.ID, b.Task.ID))
+	})
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results
+}
@@ -0,0 +1,77 @@
+package godoist
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /comments", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []Comment{
+				{ID: "c1", TaskID: "4", Content: "Remember the invoice number"},
+				{ID: "c2", TaskID: "4", Content: ContextPrefix + ` {"invoice": 1}`},
+			},
+			"next_cursor": nil,
+		})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{
+		{ID: "1", Content: "Buy groceries"},
+		{ID: "2", Content: "Pick up oatmilk", Description: "from the groceries store"},
+		{ID: "3", Content: "Call mom", Labels: []string{"family"}},
+		{ID: "4", Content: "Pay bills"},
+	})
+
+	check := func(query string, opts SearchOptions, expected ...string) {
+		t.Helper()
+		results := td.Tasks.SearchWith(query, opts)
+		ids := make([]*Task, 0, len(results))
+		for _, result := range results {
+			ids = append(ids, result.Task)
+		}
+		got := taskIDs(ids)
+		want := ""
+		for i, id := range expected {
+			if i > 0 {
+				want += " "
+			}
+			want += id
+		}
+		if got != want {
+			t.Errorf("%q: expected %q, got %q", query, want, got)
+		}
+	}
+
+	check("groceries", SearchOptions{}, "1", "2")
+	check("GROCERIS", SearchOptions{}, "1", "2")
+	check("milk", SearchOptions{}, "2")
+	check("famil", SearchOptions{}, "3")
+	check("buy groceries", SearchOptions{}, "1")
+	check("invoice", SearchOptions{})
+
+	if _, err := td.Tasks.Get("4").GetComments(); err != nil {
+		t.Fatalf("GetComments() returned error: %v", err)
+	}
+	check("invoice", SearchOptions{})
+	check("invoice", SearchOptions{Comments: true}, "4")
+
+	td.Tasks.Update([]Task{{ID: "1", Content: "Buy flowers"}})
+	check("groceries", SearchOptions{}, "2")
+	check("flowers", SearchOptions{Limit: 1}, "1")
+
+	td.Tasks.Update([]Task{{ID: "5", Content: "Milk the cow"}})
+	check("milk", SearchOptions{}, "5")
+	check("bil", SearchOptions{}, "4")
+}
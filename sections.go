@@ -0,0 +1,33 @@
+package godoist
+
+type Section struct {
+	ID           string `json:"id"`
+	ProjectID    string `json:"project_id"`
+	Name         string `json:"name"`
+	SectionOrder int    `json:"section_order"`
+}
+
+// GetSection retrieves a section by ID.
+func (t *TodoistAPI) GetSection(id string) (*Section, error) {
+	var section Section
+	if err := t.doGet("/sections/"+id, &section); err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// sectionProject returns the ID of the project a section belongs to. It is
+// taken from the cached tasks of the section when possible and fetched
+// from the API otherwise.
+func (t *TaskManager) sectionProject(sectionID string) (string, error) {
+	for _, task := range t.indexes.bySection.get(sectionID) {
+		if task.ProjectID != "" {
+			return task.ProjectID, nil
+		}
+	}
+	section, err := t.api.GetSection(sectionID)
+	if err != nil {
+		return "", err
+	}
+	return section.ProjectID, nil
+}
@@ -0,0 +1,108 @@
+package godoist
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// TaskOrder compares two tasks like cmp.Compare: negative if a sorts
+// before b, positive if after and zero if they are equal.
+type TaskOrder func(a, b *Task) int
+
+// Then breaks ties of o with next.
+func (o TaskOrder) Then(next TaskOrder) TaskOrder {
+	return func(a, b *Task) int {
+		if c := o(a, b); c != 0 {
+			return c
+		}
+		return next(a, b)
+	}
+}
+
+// Reverse inverts o.
+func (o TaskOrder) Reverse() TaskOrder {
+	return func(a, b *Task) int {
+		return o(b, a)
+	}
+}
+
+// ByPriority sorts the most urgent tasks first.
+func ByPriority(a, b *Task) int {
+	return cmp.Compare(b.Priority, a.Priority)
+}
+
+// ByDue sorts the earliest due tasks first and tasks without a due date
+// last.
+func ByDue(a, b *Task) int {
+	return compareTimes(dueTime(a), dueTime(b))
+}
+
+// ByAdded sorts the oldest tasks first.
+func ByAdded(a, b *Task) int {
+	return compareTimes(parseTimestamp(a.AddedAt), parseTimestamp(b.AddedAt))
+}
+
+// ByContent sorts tasks alphabetically by content.
+func ByContent(a, b *Task) int {
+	return cmp.Compare(a.Content, b.Content)
+}
+
+// ByChildOrder sorts sibling tasks as they are shown in Todoist.
+func ByChildOrder(a, b *Task) int {
+	return cmp.Compare(a.ChildOrder, b.ChildOrder)
+}
+
+func dueTime(task *Task) time.Time {
+	if task.Due == nil {
+		return time.Time{}
+	}
+	return task.Due.Local()
+}
+
+func parseTimestamp(value string) time.Time {
+	parsed, _ := time.Parse(time.RFC3339Nano, value)
+	return parsed
+}
+
+// compareTimes orders earlier times first and zero times last.
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.IsZero() && b.IsZero():
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	}
+	return a.Compare(b)
+}
+
+// SortBy sorts tasks by the given orders, each breaking the ties of the
+// previous one. The sort is stable and ends with the task ID, so the
+// result is deterministic.
+func SortBy(tasks []*Task, orders ...TaskOrder) {
+	slices.SortStableFunc(tasks, func(a, b *Task) int {
+		for _, order := range orders {
+			if c := order(a, b); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+// SortByPriority sorts tasks by priority, most urgent first.
+func SortByPriority(tasks []*Task) {
+	SortBy(tasks, ByPriority)
+}
+
+// SortByDue sorts tasks by due date, earliest first.
+func SortByDue(tasks []*Task) {
+	SortBy(tasks, ByDue)
+}
+
+// SortByAdded sorts tasks by creation date, oldest first.
+func SortByAdded(tasks []*Task) {
+	SortBy(tasks, ByAdded)
+}
@@ -0,0 +1,77 @@
+package godoist
+
+import (
+	"strings"
+	"testing"
+)
+
+func taskIDs(tasks []*Task) string {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestAllOrder(t *testing.T) {
+	td := NewTodoist("test-token")
+	td.Projects.Update([]Project{
+		{ID: "work", Name: "Work", ChildOrder: 2},
+		{ID: "home", Name: "Home", ChildOrder: 1},
+		{ID: "garden", Name: "Garden", ParentID: "home", ChildOrder: 1},
+	})
+	td.Tasks.Update([]Task{
+		{ID: "w1", ProjectID: "work", ChildOrder: 1},
+		{ID: "g1", ProjectID: "garden", ChildOrder: 1},
+		{ID: "h2", ProjectID: "home", ChildOrder: 2},
+		{ID: "h1", ProjectID: "home", ChildOrder: 1},
+		{ID: "h1a", ProjectID: "home", ParentID: "h1", ChildOrder: 1},
+		{ID: "ho", ProjectID: "home", ParentID: "missing", ChildOrder: 1},
+		{ID: "hc2", ProjectID: "home", ParentID: "hc1", ChildOrder: 2},
+		{ID: "hc1", ProjectID: "home", ParentID: "hc2", ChildOrder: 1},
+	})
+
+	for i := 0; i < 5; i++ {
+		if got := taskIDs(td.Tasks.All()); got != "h1 h1a h2 ho hc1 hc2 g1 w1" {
+			t.Fatalf("unexpected task order %q", got)
+		}
+		var projects []string
+		for _, project := range td.Projects.All() {
+			projects = append(projects, project.ID)
+		}
+		if got := strings.Join(projects, " "); got != "home garden work" {
+			t.Fatalf("unexpected project order %q", got)
+		}
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	tasks := []*Task{
+		{ID: "1", Priority: LOW, AddedAt: "2026-03-01T10:00:00Z", Due: &Due{Date: "2026-03-05"}},
+		{ID: "2", Priority: HIGH, AddedAt: "2026-03-01T09:00:00Z"},
+		{ID: "3", Priority: LOW, AddedAt: "2026-02-01T10:00:00Z", Due: &Due{Date: "2026-03-03"}},
+		{ID: "4", Priority: HIGH, AddedAt: "2026-01-01T10:00:00Z", Due: &Due{Date: "2026-03-04"}},
+	}
+	for _, task := range tasks {
+		if task.Due != nil {
+			task.Due.ParsedDate, _ = parseDueDate(task.Due.Date, "")
+		}
+	}
+
+	SortByAdded(tasks)
+	if got := taskIDs(tasks); got != "4 3 2 1" {
+		t.Errorf("SortByAdded: unexpected order %q", got)
+	}
+	SortByDue(tasks)
+	if got := taskIDs(tasks); got != "3 4 1 2" {
+		t.Errorf("SortByDue: unexpected order %q", got)
+	}
+	SortBy(tasks, ByPriority, ByDue)
+	if got := taskIDs(tasks); got != "4 2 3 1" {
+		t.Errorf("SortBy(priority, due): unexpected order %q", got)
+	}
+	SortBy(tasks, TaskOrder(ByPriority).Reverse().Then(ByAdded))
+	if got := taskIDs(tasks); got != "3 1 4 2" {
+		t.Errorf("SortBy(reverse priority, added): unexpected order %q", got)
+	}
+}
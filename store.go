@@ -0,0 +1,180 @@
+package godoist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot is the synced state of a Todoist client as persisted by a Store.
+type Snapshot struct {
+	SyncToken string    `json:"sync_token"`
+	SyncedAt  time.Time `json:"synced_at"`
+	Tasks     []Task    `json:"tasks"`
+	Projects  []Project `json:"projects"`
+	Contexts  []Comment `json:"contexts"`
+}
+
+// Store persists snapshots between process runs.
+type Store interface {
+	// Load returns the last saved snapshot, or nil if nothing was saved yet.
+	Load() (*Snapshot, error)
+	Save(snapshot *Snapshot) error
+}
+
+// FileStore is a Store that keeps the snapshot in a single JSON file.
+type FileStore struct {
+	Path string
+}
+
+// NewFileStore creates a file-backed store at the given path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+func (f *FileStore) Load() (*Snapshot, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse cache %q: %w", f.Path, err)
+	}
+	return &snapshot, nil
+}
+
+// Save writes the snapshot to a temporary file and renames it into place so
+// that a crash never leaves a truncated cache behind.
+func (f *FileStore) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.Path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// UseStore makes the client persist its synced state to store. Context
+// comments read or written by the client are persisted as well, see
+// CacheContextReads.
+func (t *Todoist) UseStore(store Store) {
+	t.store = store
+	t.Tasks.cacheContexts = store != nil
+}
+
+// CacheContextReads makes context reads use the cached context comments
+// instead of requesting them. A cached comment is dropped when a sync
+// reports a changed note count or update time for its task, but edits of
+// comments by other clients change neither, so they are missed until this
+// client writes the context. Only enable it if that is acceptable.
+func (t *Todoist) CacheContextReads(enabled bool) {
+	t.Tasks.readCachedContexts = enabled
+}
+
+// LoadCache populates the managers from the configured store. It returns
+// false if no store is configured or nothing has been saved yet.
+func (t *Todoist) LoadCache() (bool, error) {
+	if t.store == nil {
+		return false, nil
+	}
+	snapshot, err := t.store.Load()
+	if err != nil || snapshot == nil {
+		return false, err
+	}
+
+	t.Tasks.replace(snapshot.Tasks)
+	t.Projects.replace(snapshot.Projects)
+	for _, comment := range snapshot.Contexts {
+		if _, exists := t.Tasks.tasks[comment.TaskID]; exists {
+			t.Tasks.cacheContextComment(comment)
+		}
+	}
+	t.syncToken = snapshot.SyncToken
+	t.syncedAt = snapshot.SyncedAt
+	return true, nil
+}
+
+// SaveCache writes the current state of the managers to the configured store.
+func (t *Todoist) SaveCache() error {
+	if t.store == nil {
+		return nil
+	}
+
+	snapshot := &Snapshot{
+		SyncToken: t.syncToken,
+		SyncedAt:  t.syncedAt,
+		Tasks:     make([]Task, 0, len(t.Tasks.tasks)),
+		Projects:  make([]Project, 0, len(t.Projects.projects)),
+		Contexts:  make([]Comment, 0, len(t.Tasks.contexts)),
+	}
+	for _, task := range t.Tasks.tasks {
+		snapshot.Tasks = append(snapshot.Tasks, *task)
+	}
+	for _, project := range t.Projects.projects {
+		snapshot.Projects = append(snapshot.Projects, *project)
+	}
+	for _, comments := range t.Tasks.contexts {
+		for _, comment := range comments {
+			snapshot.Contexts = append(snapshot.Contexts, comment)
+		}
+	}
+	return t.store.Save(snapshot)
+}
+
+// IsFresh reports whether the last sync happened within MaxAge. A zero
+// MaxAge treats cached data as always stale; a negative MaxAge never
+// expires it.
+func (t *Todoist) IsFresh() bool {
+	if t.syncedAt.IsZero() || t.MaxAge == 0 {
+		return false
+	}
+	if t.MaxAge < 0 {
+		return true
+	}
+	return time.Since(t.syncedAt) < t.MaxAge
+}
+
+// SyncedAt returns the time of the last successful sync, including syncs
+// restored from the store.
+func (t *Todoist) SyncedAt() time.Time {
+	return t.syncedAt
+}
+
+// SyncIfStale loads the cached state and only syncs with the server if the
+// cache is missing or older than MaxAge.
+func (t *Todoist) SyncIfStale() error {
+	if t.syncedAt.IsZero() {
+		if _, err := t.LoadCache(); err != nil {
+			t.logger.Warn("ignoring unreadable cache", "error", err)
+		}
+	}
+	if t.IsFresh() {
+		return nil
+	}
+	return t.Sync()
+}
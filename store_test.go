@@ -0,0 +1,135 @@
+package godoist
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "cache", "state.json"))
+
+	snapshot, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() on missing file returned error: %v", err)
+	}
+	if snapshot != nil {
+		t.Fatal("expected nil snapshot for missing file")
+	}
+
+	syncedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	err = store.Save(&Snapshot{
+		SyncToken: "token-1",
+		SyncedAt:  syncedAt,
+		Tasks:     []Task{{ID: "1", Content: "Buy milk", Due: &Due{Date: "2026-03-02"}}},
+		Projects:  []Project{{ID: "100", Name: "Inbox"}},
+		Contexts:  []Comment{{ID: "c1", TaskID: "1", Content: ContextPrefix + ` {"a":1}`}},
+	})
+	if err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	snapshot, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if snapshot.SyncToken != "token-1" || !snapshot.SyncedAt.Equal(syncedAt) {
+		t.Errorf("unexpected sync state: %q %v", snapshot.SyncToken, snapshot.SyncedAt)
+	}
+	if len(snapshot.Tasks) != 1 || snapshot.Tasks[0].Due.ParsedDate.IsZero() {
+		t.Errorf("expected task with parsed due date, got %+v", snapshot.Tasks)
+	}
+	if len(snapshot.Projects) != 1 || len(snapshot.Contexts) != 1 {
+		t.Errorf("expected 1 project and 1 context, got %d and %d", len(snapshot.Projects), len(snapshot.Contexts))
+	}
+}
+
+func TestSyncIfStale(t *testing.T) {
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results":     []Task{{ID: "1", Content: "Buy milk", ProjectID: "100"}},
+			"next_cursor": nil,
+		})
+	})
+	mux.HandleFunc("GET /projects", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results":     []Project{{ID: "100", Name: "Inbox"}},
+			"next_cursor": nil,
+		})
+	})
+	mux.HandleFunc("GET /comments", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results":     []Comment{{ID: "c1", TaskID: "1", Content: ContextPrefix + ` {"source":"email"}`}},
+			"next_cursor": nil,
+		})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	config := &Config{Token: "test-token", CachePath: filepath.Join(t.TempDir(), "state.json"), CacheMaxAge: 60, CacheContexts: true}
+
+	first := NewTodoistWithConfig(config)
+	if err := first.SyncIfStale(); err != nil {
+		t.Fatalf("SyncIfStale() returned error: %v", err)
+	}
+	if _, err := first.Tasks.Get("1").GetContext(); err != nil {
+		t.Fatalf("GetContext() returned error: %v", err)
+	}
+	if err := first.SaveCache(); err != nil {
+		t.Fatalf("SaveCache() returned error: %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("expected 2 requests after first run, got %d", requests)
+	}
+
+	second := NewTodoistWithConfig(config)
+	if err := second.SyncIfStale(); err != nil {
+		t.Fatalf("SyncIfStale() returned error: %v", err)
+	}
+	if second.Tasks.Get("1") == nil || second.Projects.Get("100") == nil {
+		t.Fatal("expected cached task and project")
+	}
+	context, err := second.Tasks.Get("1").GetContext()
+	if err != nil {
+		t.Fatalf("GetContext() returned error: %v", err)
+	}
+	if context["source"] != "email" {
+		t.Errorf("expected cached context, got %v", context)
+	}
+	if requests != 2 {
+		t.Errorf("expected fresh cache to avoid requests, got %d", requests)
+	}
+
+	second.MaxAge = 0
+	if err := second.SyncIfStale(); err != nil {
+		t.Fatalf("SyncIfStale() returned error: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected stale cache to sync, got %d requests", requests)
+	}
+
+	uncached := *config
+	uncached.CacheContexts = false
+	third := NewTodoistWithConfig(&uncached)
+	if err := third.SyncIfStale(); err != nil {
+		t.Fatalf("SyncIfStale() returned error: %v", err)
+	}
+	if _, err := third.Tasks.Get("1").GetContext(); err != nil {
+		t.Fatalf("GetContext() returned error: %v", err)
+	}
+	if requests != 4 {
+		t.Errorf("expected contexts to be fetched unless cached reads are enabled, got %d requests", requests)
+	}
+}
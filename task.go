@@ -40,16 +40,11 @@ func (d *Due) UnmarshalJSON(data []byte) error {
 	}
 
 	if d.Date != "" {
-		parsedDate, err := time.Parse("2006-01-02T15:04:05", d.Date)
+		parsedDate, err := parseDueDate(d.Date, d.Timezone)
 		if err != nil {
-			parsedDate, err := time.Parse("2006-01-02", d.Date)
-			if err != nil {
-				return err
-			}
-			d.ParsedDate = parsedDate
-		} else {
-			d.ParsedDate = parsedDate
+			return err
 		}
+		d.ParsedDate = parsedDate
 	}
 
 	return nil
@@ -68,7 +63,7 @@ func (d *Deadline) UnmarshalJSON(data []byte) error {
 	}
 
 	if d.Date != "" {
-		parsedDate, err := time.Parse("2006-01-02", d.Date)
+		parsedDate, err := parseDueDate(d.Date, "")
 		if err != nil {
 			return err
 		}
@@ -92,6 +87,7 @@ type Task struct {
 	ParentID    string         `json:"parent_id"`
 	Labels      []string       `json:"labels"`
 	Checked     bool           `json:"checked"`
+	IsDeleted   bool           `json:"is_deleted"`
 	AddedAt     string         `json:"added_at"`
 	UpdatedAt   string         `json:"updated_at"`
 	CompletedAt string         `json:"completed_at"`
@@ -100,6 +96,9 @@ type Task struct {
 	IsCollapsed bool           `json:"is_collapsed"`
 	URL         string         `json:"url"`
 	manager     *TaskManager   `json:"-"`
+	// contexts holds the contexts preloaded by TaskManager.LoadContexts by
+	// namespace, nil if they have not been loaded.
+	contexts map[string]cachedContext
 }
 
 func (t *Task) UnmarshalJSON(data []byte) error {
@@ -128,14 +127,9 @@ func (t *Task) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// GetChildren returns the direct subtasks ordered by child order.
 func (t *Task) GetChildren() []*Task {
-	var tasks = make([]*Task, 0)
-	for _, task := range t.manager.tasks {
-		if task.ParentID == t.ID {
-			tasks = append(tasks, task)
-		}
-	}
-	return tasks
+	return t.manager.indexes.byParent.get(t.ID)
 }
 
 func (t *Task) String() string {
@@ -148,56 +142,88 @@ func (t *Task) AddLabel(label string) {
 			return
 		}
 	}
-	new_label := append(t.Labels, label)
-	t.Update("labels", new_label)
+	new_label := append(append([]string{}, t.Labels...), label)
+	t.Patch().Labels(new_label...).Apply()
 }
 
 func (t *Task) RemoveLabel(label string) error {
 	for i, existingLabel := range t.Labels {
 		if existingLabel == label {
-			new_labels := append(t.Labels[:i], t.Labels[i+1:]...)
-			t.Update("labels", new_labels)
-			return nil
+			new_labels := append(append([]string{}, t.Labels[:i]...), t.Labels[i+1:]...)
+			return t.Patch().Labels(new_labels...).Apply()
 		}
 	}
 	return fmt.Errorf("label not found: %s", label)
 }
 
+// Update changes a single field of the task. Prefer Patch, which checks
+// value types at compile time and can change several fields at once.
 func (t *Task) Update(key string, value interface{}) error {
+	patch := t.Patch()
+	var ok bool
 	switch key {
 	case "content", "Content":
-		t.Content = value.(string)
+		ok = assign(value, patch.Content)
 	case "description", "Description":
-		t.Description = value.(string)
+		ok = assign(value, patch.Description)
+	case "priority", "Priority":
+		ok = assign(value, patch.Priority)
+	case "labels", "Labels":
+		ok = assign(value, func(labels []string) *TaskPatch { return patch.Labels(labels...) })
 	case "project_id", "ProjectID":
-		t.ProjectID = value.(string)
+		ok = assign(value, func(id string) *TaskPatch {
+			return patch.set("project_id", id, func(t *Task) { t.ProjectID = id })
+		})
 	case "section_id", "SectionID":
-		t.SectionID = value.(string)
+		ok = assign(value, func(id string) *TaskPatch {
+			return patch.set("section_id", id, func(t *Task) { t.SectionID = id })
+		})
+	case "parent_id", "ParentID":
+		ok = assign(value, func(id string) *TaskPatch {
+			return patch.set("parent_id", id, func(t *Task) { t.ParentID = id })
+		})
 	case "child_order", "ChildOrder":
-		t.ChildOrder = value.(int)
-	case "priority", "Priority":
-		t.Priority = value.(PRIORITY_LEVEL)
+		ok = assign(value, func(order int) *TaskPatch {
+			return patch.set("child_order", order, func(t *Task) { t.ChildOrder = order })
+		})
 	case "deadline", "Deadline":
-		t.Deadline = value.(*Deadline)
+		ok = assign(value, patch.deadline)
 	case "due", "Due":
-		t.Due = value.(*Due)
+		ok = assign(value, patch.Due)
 	case "duration", "Duration":
-		t.Duration = value.(*Duration)
-	case "parent_id", "ParentID":
-		t.ParentID = value.(string)
-	case "labels", "Labels":
-		t.Labels = value.([]string)
+		ok = assign(value, patch.Duration)
 	default:
 		t.manager.api.logger.Error("Unknown/unsupported Update", "Command", key, "Task", t)
 		return errors.New("unknown/unsupported Update")
 	}
-	return t.manager.api.UpdateTask(t.ID, map[string]interface{}{key: value})
+	if !ok {
+		return fmt.Errorf("invalid value for %s: %T", key, value)
+	}
+	return patch.Apply()
 }
 
 func (t *Task) Close() error {
-	return t.manager.api.CloseTask(t.ID)
+	cmd := NewCommand("item_close", map[string]interface{}{"id": t.ID})
+	err := t.manager.submit(t, func() error {
+		return t.manager.api.CloseTask(t.ID)
+	}, cmd)
+	if err != nil {
+		return err
+	}
+	t.Checked = true
+	t.manager.refresh(t)
+	return nil
 }
 
 func (t *Task) Reopen() error {
-	return t.manager.api.ReopenTask(t.ID)
+	cmd := NewCommand("item_uncomplete", map[string]interface{}{"id": t.ID})
+	err := t.manager.submit(t, func() error {
+		return t.manager.api.ReopenTask(t.ID)
+	}, cmd)
+	if err != nil {
+		return err
+	}
+	t.Checked = false
+	t.manager.refresh(t)
+	return nil
 }
@@ -1,9 +1,11 @@
 package godoist
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	"sync"
+	"time"
 )
 
 type Todoist struct {
@@ -13,6 +15,12 @@ type Todoist struct {
 	Tasks      TaskManager
 	Projects   ProjectManager
 	UseSyncAPI bool
+	// MaxAge is how long cached state is considered fresh by SyncIfStale.
+	MaxAge time.Duration
+
+	store     Store
+	syncToken string
+	syncedAt  time.Time
 }
 
 // NewTodoist creates a new Todoist client
@@ -47,14 +55,42 @@ func NewTodoistWithConfig(config *Config) *Todoist {
 	manager.Projects = &aux.Projects
 	aux.Tasks.Manager = &manager
 	aux.Projects.Manager = &manager
+	aux.MaxAge = time.Duration(config.CacheMaxAge) * time.Second
+	if config.CachePath != "" {
+		aux.UseStore(NewFileStore(config.CachePath))
+		aux.CacheContextReads(config.CacheContexts)
+	}
+	if config.JournalPath != "" {
+		aux.EnableOffline(NewJournal(config.JournalPath))
+	}
+	if len(config.ContextKeys) > 0 {
+		keys, err := NewStaticKeys(config.ContextKeys...)
+		if err != nil {
+			logger.Error("invalid context keys, context writes will fail", "error", err)
+			aux.EncryptContexts(failingKeys{err})
+		} else {
+			aux.EncryptContexts(keys)
+		}
+	}
 	return aux
 }
 
 func (t *Todoist) Sync() error {
+	var err error
 	if t.UseSyncAPI {
-		return t.syncViaSyncAPI()
+		err = t.syncViaSyncAPI()
+	} else {
+		err = t.syncViaRestAPI()
 	}
-	return t.syncViaRestAPI()
+	if err != nil {
+		return err
+	}
+
+	t.syncedAt = time.Now()
+	if err := t.SaveCache(); err != nil {
+		return fmt.Errorf("save cache: %w", err)
+	}
+	return nil
 }
 
 func (t *Todoist) syncViaRestAPI() error {
@@ -86,20 +122,29 @@ func (t *Todoist) syncViaRestAPI() error {
 		return projErr
 	}
 
-	t.Tasks.Update(tasks)
-	t.Projects.Update(projects)
+	t.Tasks.replace(tasks)
+	t.Projects.replace(projects)
+	// The REST API has no notion of sync tokens, the next Sync API call
+	// must start over with a full sync.
+	t.syncToken = ""
 	return nil
 }
 
 func (t *Todoist) syncViaSyncAPI() error {
-	syncData, err := t.API.SyncResources([]string{"items", "projects"})
+	syncData, err := t.API.SyncResourcesSince(t.syncToken, []string{"items", "projects"})
 	if err != nil {
 		t.logger.Error(err.Error())
 		return err
 	}
 
-	t.Tasks.Update(syncData.Items)
-	t.Projects.Update(syncData.Projects)
+	if syncData.FullSync || t.syncToken == "" {
+		t.Tasks.replace(syncData.Items)
+		t.Projects.replace(syncData.Projects)
+	} else {
+		t.Tasks.merge(syncData.Items)
+		t.Projects.merge(syncData.Projects)
+	}
+	t.syncToken = syncData.SyncToken
 	return nil
 }
 
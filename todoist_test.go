@@ -150,3 +150,40 @@ func TestSyncPagination(t *testing.T) {
 		t.Fatal("expected both tasks to be present after paginating")
 	}
 }
+
+func TestSyncAPIIncremental(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sync", func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		resp := SyncResponse{SyncToken: "t1", FullSync: true, Items: []Task{{ID: "1"}, {ID: "2"}}, Projects: []Project{{ID: "100"}}}
+		if payload["sync_token"] == "t1" {
+			resp = SyncResponse{SyncToken: "t2", Items: []Task{{ID: "1", Checked: true}}, Projects: []Project{{ID: "100", IsDeleted: true}}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orig := APIURL
+	APIURL = srv.URL
+	defer func() { APIURL = orig }()
+
+	td := NewTodoist("test-token")
+	td.UseSyncAPI = true
+	for i := 0; i < 2; i++ {
+		if err := td.Sync(); err != nil {
+			t.Fatalf("Sync() returned error: %v", err)
+		}
+	}
+	if td.Tasks.Get("1") != nil || td.Tasks.Get("2") == nil || td.Projects.Get("100") != nil {
+		t.Error("expected incremental sync to remove completed tasks and deleted projects")
+	}
+
+	// Update merges completed tasks like any other.
+	td.Tasks.Update([]Task{{ID: "3", Checked: true}})
+	if td.Tasks.Get("3") == nil {
+		t.Error("expected Update to keep completed tasks")
+	}
+}
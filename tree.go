@@ -0,0 +1,193 @@
+package godoist
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
+// TaskTree is a snapshot of the task hierarchy of a TaskManager.
+type TaskTree struct {
+	roots    []*Task
+	children map[string][]*Task
+	depth    map[string]int
+	// Orphans are tasks whose parent is not in the cache. They are
+	// traversed like roots.
+	Orphans []*Task
+	// Cyclic are tasks that cannot be reached from any root because their
+	// parents form a cycle.
+	Cyclic []*Task
+	// byProject orders tasks by the project order of their project.
+	byProject func(a, b *Task) int
+}
+
+// sortSiblings orders tasks by child order, falling back to the ID to
+// keep the order stable.
+func sortSiblings(tasks []*Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].ChildOrder != tasks[j].ChildOrder {
+			return tasks[i].ChildOrder < tasks[j].ChildOrder
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
+
+// Tree builds the task hierarchy from the cached tasks.
+func (t *TaskManager) Tree() *TaskTree {
+	tree := &TaskTree{
+		children: make(map[string][]*Task),
+		depth:    make(map[string]int, len(t.tasks)),
+	}
+	for _, task := range t.tasks {
+		if task.ParentID == "" {
+			tree.roots = append(tree.roots, task)
+		} else if _, exists := t.tasks[task.ParentID]; !exists {
+			tree.Orphans = append(tree.Orphans, task)
+		} else {
+			tree.children[task.ParentID] = append(tree.children[task.ParentID], task)
+		}
+	}
+
+	sortSiblings(tree.roots)
+	ranks := t.projectRanks()
+	rank := func(task *Task) int {
+		if r, ok := ranks[task.ProjectID]; ok {
+			return r
+		}
+		return len(ranks)
+	}
+	tree.byProject = func(a, b *Task) int {
+		return cmp.Or(cmp.Compare(rank(a), rank(b)), cmp.Compare(a.ProjectID, b.ProjectID))
+	}
+	slices.SortStableFunc(tree.roots, tree.byProject)
+	sortSiblings(tree.Orphans)
+	for _, children := range tree.children {
+		sortSiblings(children)
+	}
+
+	tree.Walk(func(task *Task, depth int) bool {
+		tree.depth[task.ID] = depth
+		return true
+	})
+	for _, task := range t.tasks {
+		if _, visited := tree.depth[task.ID]; !visited {
+			tree.Cyclic = append(tree.Cyclic, task)
+		}
+	}
+	sortSiblings(tree.Cyclic)
+	return tree
+}
+
+// projectRanks returns the position of every cached project in project
+// order, used to group tasks by project.
+func (t *TaskManager) projectRanks() map[string]int {
+	ranks := make(map[string]int)
+	if t.Manager == nil || t.Manager.Projects == nil {
+		return ranks
+	}
+	for i, project := range t.Manager.Projects.All() {
+		ranks[project.ID] = i
+	}
+	return ranks
+}
+
+// Roots returns the top-level tasks, grouped by project in project order
+// and ordered by child order.
+func (tree *TaskTree) Roots() []*Task {
+	return tree.roots
+}
+
+// Children returns the direct subtasks of task ordered by child order.
+func (tree *TaskTree) Children(task *Task) []*Task {
+	return tree.children[task.ID]
+}
+
+// Depth returns the nesting level of task, 0 for roots and orphans and -1
+// for tasks that are not part of the tree.
+func (tree *TaskTree) Depth(task *Task) int {
+	depth, ok := tree.depth[task.ID]
+	if !ok {
+		return -1
+	}
+	return depth
+}
+
+// Walk visits all tasks in pre-order, roots first and orphans after them.
+// Returning false from fn skips the subtasks of the visited task.
+func (tree *TaskTree) Walk(fn func(task *Task, depth int) bool) {
+	tree.walk(tree.roots, 0, fn)
+	tree.walk(tree.Orphans, 0, fn)
+}
+
+// walk visits tasks and their subtasks in pre-order.
+func (tree *TaskTree) walk(tasks []*Task, depth int, fn func(task *Task, depth int) bool) {
+	for _, task := range tasks {
+		if fn(task, depth) {
+			tree.walk(tree.children[task.ID], depth+1, fn)
+		}
+	}
+}
+
+// Descendants returns all subtasks of task in pre-order.
+func (tree *TaskTree) Descendants(task *Task) []*Task {
+	var tasks []*Task
+	seen := map[string]bool{task.ID: true}
+	var visit func(parent *Task)
+	visit = func(parent *Task) {
+		for _, child := range tree.children[parent.ID] {
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			tasks = append(tasks, child)
+			visit(child)
+		}
+	}
+	visit(task)
+	return tasks
+}
+
+// Parent returns the parent task, or nil for top-level tasks and tasks
+// whose parent is not in the cache.
+func (t *Task) Parent() *Task {
+	if t.ParentID == "" {
+		return nil
+	}
+	return t.manager.Get(t.ParentID)
+}
+
+// Ancestors returns the parents of the task, closest first. It stops at a
+// parent that is missing from the cache or would close a cycle.
+func (t *Task) Ancestors() []*Task {
+	var ancestors []*Task
+	seen := map[string]bool{t.ID: true}
+	for parent := t.Parent(); parent != nil && !seen[parent.ID]; parent = parent.Parent() {
+		seen[parent.ID] = true
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// Depth returns the nesting level of the task, 0 for top-level tasks.
+func (t *Task) Depth() int {
+	return len(t.Ancestors())
+}
+
+// Descendants returns all subtasks of the task in pre-order.
+func (t *Task) Descendants() []*Task {
+	var tasks []*Task
+	seen := map[string]bool{t.ID: true}
+	var visit func(parent *Task)
+	visit = func(parent *Task) {
+		for _, child := range parent.GetChildren() {
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			tasks = append(tasks, child)
+			visit(child)
+		}
+	}
+	visit(t)
+	return tasks
+}
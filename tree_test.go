@@ -0,0 +1,55 @@
+package godoist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaskTree(t *testing.T) {
+	td := NewTodoist("test-token")
+	td.Tasks.Update([]Task{
+		{ID: "a", ProjectID: "100", ChildOrder: 2},
+		{ID: "b", ProjectID: "100", ChildOrder: 1},
+		{ID: "b2", ProjectID: "100", ParentID: "b", ChildOrder: 2},
+		{ID: "b1", ProjectID: "100", ParentID: "b", ChildOrder: 1},
+		{ID: "b1x", ProjectID: "100", ParentID: "b1", ChildOrder: 1},
+		{ID: "orphan", ProjectID: "100", ParentID: "gone"},
+		{ID: "loop1", ProjectID: "100", ParentID: "loop2"},
+		{ID: "loop2", ProjectID: "100", ParentID: "loop1"},
+	})
+
+	tree := td.Tasks.Tree()
+	var visited []string
+	tree.Walk(func(task *Task, depth int) bool {
+		visited = append(visited, strings.Repeat("-", depth)+task.ID)
+		return true
+	})
+	expected := "b -b1 --b1x -b2 a orphan"
+	if got := strings.Join(visited, " "); got != expected {
+		t.Errorf("expected pre-order %q, got %q", expected, got)
+	}
+
+	if len(tree.Orphans) != 1 || tree.Orphans[0].ID != "orphan" {
+		t.Errorf("expected orphan to be detected, got %v", tree.Orphans)
+	}
+	if len(tree.Cyclic) != 2 {
+		t.Errorf("expected 2 cyclic tasks, got %v", tree.Cyclic)
+	}
+
+	leaf := td.Tasks.Get("b1x")
+	if leaf.Parent().ID != "b1" || leaf.Depth() != 2 || tree.Depth(leaf) != 2 {
+		t.Errorf("unexpected parent or depth for %s", leaf.ID)
+	}
+	if ancestors := leaf.Ancestors(); len(ancestors) != 2 || ancestors[1].ID != "b" {
+		t.Errorf("unexpected ancestors: %v", ancestors)
+	}
+	if descendants := td.Tasks.Get("b").Descendants(); len(descendants) != 3 || descendants[1].ID != "b1x" {
+		t.Errorf("unexpected descendants: %v", descendants)
+	}
+	if children := td.Tasks.Get("b").GetChildren(); children[0].ID != "b1" || children[1].ID != "b2" {
+		t.Errorf("expected children in child order, got %v", children)
+	}
+	if ancestors := td.Tasks.Get("loop1").Ancestors(); len(ancestors) != 1 {
+		t.Errorf("expected cycle to stop ancestors, got %v", ancestors)
+	}
+}